@@ -0,0 +1,60 @@
+package frs
+
+import (
+	"context"
+	"time"
+)
+
+// APIKey is a long lived credential for server-to-server integrations. Keys
+// act on behalf of their user but only within their scopes.
+type APIKey struct {
+	ID     int64  `json:"id"`
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+	// first characters of the key so users can tell them apart
+	Prefix string `json:"prefix"`
+	// plain text key, only set in the response that created it
+	Key        string       `json:"key,omitempty"`
+	Scopes     []Permission `json:"scopes"`
+	ExpiresAt  *time.Time   `json:"expires_at"`
+	LastUsedAt *time.Time   `json:"last_used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+func (k *APIKey) Validate() error {
+	if k.Name == "" {
+		return Errorf(EBADREQUEST, "api key name required")
+	}
+
+	if len(k.Scopes) == 0 {
+		return Errorf(EBADREQUEST, "api key scopes required")
+	}
+
+	for _, scope := range k.Scopes {
+		if !scope.Valid() {
+			return Errorf(EBADREQUEST, "invalid api key scope %q", scope)
+		}
+	}
+
+	return nil
+}
+
+// HasScope reports whether the key was granted perm.
+func (k *APIKey) HasScope(perm Permission) bool {
+	for _, scope := range k.Scopes {
+		if scope == perm {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKeyService interface {
+	// sets Key to the plain text key, it cannot be retrieved again
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	FindAPIKeys(ctx context.Context, userID int64) ([]*APIKey, error)
+	// return NOTFOUND Error
+	RevokeAPIKey(ctx context.Context, userID, id int64) error
+	// return UNAUTHORIZED Error if the key is unknown, revoked or expired
+	AuthenticateAPIKey(ctx context.Context, key string) (*User, *APIKey, error)
+}
@@ -1,6 +1,64 @@
 package frs
 
+import (
+	"context"
+	"time"
+)
+
 type Auth struct {
-	ID          int64  `json:"id"`
-	AccessToken string `json:"access_token"`
+	ID          int64     `json:"id"`
+	SessionID   int64     `json:"session_id"`
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type Credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (c *Credentials) Validate() error {
+	if c.Email == "" {
+		return Errorf(EBADREQUEST, "email required")
+	}
+
+	if c.Password == "" {
+		return Errorf(EBADREQUEST, "password required")
+	}
+
+	return nil
+}
+
+// Identity is a user as asserted by an external OpenID Connect provider.
+type Identity struct {
+	Provider      string `json:"provider"`
+	Subject       string `json:"subject"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+func (i *Identity) Validate() error {
+	if i.Provider == "" || i.Subject == "" {
+		return Errorf(EBADREQUEST, "identity provider and subject required")
+	}
+
+	if i.Email == "" || !validEmail(i.Email) {
+		return Errorf(EBADREQUEST, "identity provider did not return a valid email")
+	}
+
+	return nil
+}
+
+type AuthService interface {
+	// return UNAUTHORIZED Error on bad credentials
+	Login(ctx context.Context, creds *Credentials) (*Auth, error)
+	// return UNAUTHORIZED Error if the token is invalid, expired or its
+	// session was revoked
+	Authenticate(ctx context.Context, token string) (*User, *Session, error)
+	// logs in the user linked to the identity. Unlinked identities are linked
+	// to the signed in user, to the passwordless user with the same verified
+	// email or get a new passwordless user. return CONFLICT Error if a user
+	// with a password has the email, they must sign in to link the identity.
+	LoginWithIdentity(ctx context.Context, identity *Identity) (*Auth, error)
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/postgres"
+)
+
+const exportUsage = `usage: frs export <users|fund-raisers> [flags]
+
+writes one json object per line to stdout or the -o file`
+
+// rows fetched per query while exporting
+const exportPageSize = 500
+
+func runExport(args []string) error {
+	if len(args) == 0 {
+		return usageError(exportUsage)
+	}
+
+	resource := args[0]
+	fs := flag.NewFlagSet("export "+resource, flag.ExitOnError)
+	output := fs.String("o", "", "Sets the file to write to instead of stdout")
+	config, err := loadConfig(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	var export func(*postgres.DB, *json.Encoder) (int, error)
+	switch resource {
+	case "users":
+		export = exportUsers
+	case "fund-raisers":
+		export = exportFundRaisers
+	default:
+		return usageError(fmt.Sprintf("unknown export %q\n%s", resource, exportUsage))
+	}
+
+	db, err := openDB(config, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	buf := bufio.NewWriter(w)
+	n, err := export(db, json.NewEncoder(buf))
+	if err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d %s\n", n, resource)
+	return nil
+}
+
+// exports page through with cursors so rows inserted meanwhile don't shift
+// pages
+
+func exportUsers(db *postgres.DB, enc *json.Encoder) (int, error) {
+	userService := postgres.NewUserService(db)
+	filter := &frs.FilterUser{Limit: exportPageSize}
+
+	n := 0
+	for {
+		users, _, err := userService.FindUsers(systemContext(), filter)
+		if err != nil {
+			return n, err
+		}
+		for _, user := range users {
+			if err := enc.Encode(user); err != nil {
+				return n, err
+			}
+		}
+		n += len(users)
+
+		if len(users) < exportPageSize {
+			return n, nil
+		}
+		last := users[len(users)-1]
+		filter.Cursor = frs.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+}
+
+func exportFundRaisers(db *postgres.DB, enc *json.Encoder) (int, error) {
+	fundRaiserService := postgres.NewFundRaiserService(db)
+	filter := &frs.FilterFundRaiser{Limit: exportPageSize}
+
+	n := 0
+	for {
+		fundRaisers, _, err := fundRaiserService.FindFundRaiser(systemContext(), filter)
+		if err != nil {
+			return n, err
+		}
+		for _, fundRaiser := range fundRaisers {
+			if err := enc.Encode(fundRaiser); err != nil {
+				return n, err
+			}
+		}
+		n += len(fundRaisers)
+
+		if len(fundRaisers) < exportPageSize {
+			return n, nil
+		}
+		last := fundRaisers[len(fundRaisers)-1]
+		filter.Cursor = frs.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+}
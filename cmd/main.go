@@ -2,121 +2,314 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/TezzBhandari/frs"
 	"github.com/TezzBhandari/frs/http"
+	"github.com/TezzBhandari/frs/inmem"
+	"github.com/TezzBhandari/frs/oidc"
 	"github.com/TezzBhandari/frs/postgres"
-	"github.com/rs/zerolog"
+	"github.com/TezzBhandari/frs/report"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
-var (
-	addr  string
-	debug bool
-	dsn   string
-)
+const usage = `usage: frs <command> [flags]
+
+commands:
+  serve                 run the api server, the default when no command is given
+  migrate               apply or roll back database migrations
+  seed                  insert demo users and fund raisers
+  user create-admin     create an admin account
+  user reset-password   set a new password and log the user out everywhere
+  export                write users or fund raisers as json lines
+  config print          print the effective config with secrets redacted
 
-func init() {
-	flag.StringVar(&addr, "addr", "", "Specifies the tcp server address for server to listen on")
-	flag.BoolVar(&debug, "debug", false, "Sets log level flag to default")
-	flag.StringVar(&dsn, "dsn", "", "Sets database dsn")
+run "frs <command> -h" for the flags of a command
 
-	flag.Parse()
+every command reads its config from a yaml or toml file given by -config or
+FRS_CONFIG, then FRS_* environment variables, then flags, later ones winning`
 
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	if debug {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-		log.Debug().Msg("Log level set to Debug")
+func main() {
+	args := os.Args[1:]
+	// no command keeps the old "frs -addr ... -dsn ..." invocation working
+	command := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
 	}
 
-	if dsn == "" {
-		log.Info().Msg("Set -dsn flag")
-		os.Exit(1)
+	var err error
+	switch command {
+	case "serve":
+		err = runServe(args)
+	case "migrate":
+		err = runMigrate(args)
+	case "seed":
+		err = runSeed(args)
+	case "user":
+		err = runUser(args)
+	case "export":
+		err = runExport(args)
+	case "config":
+		err = runConfig(args)
+	case "help":
+		fmt.Println(usage)
+	default:
+		err = usageError(fmt.Sprintf("unknown command %q\n%s", command, usage))
 	}
 
-	if addr == "" {
-		log.Info().Msg("Set -addr flag")
+	var usageErr usageError
+	if errors.As(err, &usageErr) {
+		fmt.Fprintln(os.Stderr, usageErr)
+		os.Exit(2)
+	} else if err != nil {
+		log.Error().Err(err).Msg("")
 		os.Exit(1)
 	}
+}
 
+// usageError is printed as is instead of being logged.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
 }
 
-func main() {
+func runServe(args []string) error {
+	config, err := loadConfig(flag.NewFlagSet("serve", flag.ExitOnError), args)
+	if err != nil {
+		return err
+	}
+
+	if config.HTTP.Addr == "" {
+		return fmt.Errorf("set -addr flag, FRS_ADDR or http.addr")
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	sigChan := make(chan os.Signal, 1)
 
-	signal.Notify(sigChan, os.Interrupt)
+	// orchestrators stop containers with SIGTERM
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-sigChan
 		cancel()
 	}()
 
-	m := NewMain()
+	m := NewMain(config)
 	if err := m.run(); err != nil {
-		log.Error().Err(err).Msg("")
-		if err = m.close(); err != nil {
+		if err := m.close(); err != nil {
 			log.Error().Err(err).Msg("")
 		}
-		os.Exit(1)
+		return err
 	}
 
 	<-ctx.Done()
 
 	log.Info().Msg("interrupt triggered")
 
-	if err := m.close(); err != nil {
-		log.Error().Err(err).Msg("")
-		os.Exit(1)
-	}
-
+	return m.close()
 }
 
 type Main struct {
+	Config     *Config
 	HttpServer *http.Server
 	DB         *postgres.DB
+
+	// nil when tracing is disabled
+	TracerProvider *sdktrace.TracerProvider
+	// nil unless reports go to a file
+	ReportFile *report.FileReporter
+	// nil unless a reporter is configured, sends reports in the background
+	ReportQueue *report.Queue
 }
 
-func NewMain() *Main {
+func NewMain(config *Config) *Main {
 	return &Main{
+		Config:     config,
 		HttpServer: http.NewHttpServer(),
-		DB:         postgres.NewDB(dsn),
 	}
 }
 
 func (m *Main) run() error {
-	m.HttpServer.Addr = addr
+	m.HttpServer.Addr = m.Config.HTTP.Addr
+	m.HttpServer.AdminAddr = m.Config.HTTP.AdminAddr
+	m.HttpServer.ReadTimeout = m.Config.HTTP.ReadTimeout
+	m.HttpServer.WriteTimeout = m.Config.HTTP.WriteTimeout
+	m.HttpServer.IdleTimeout = m.Config.HTTP.IdleTimeout
+	m.HttpServer.ShutdownTimeout = m.Config.HTTP.ShutdownTimeout
+	m.HttpServer.ShutdownDelay = m.Config.HTTP.ShutdownDelay
+
+	// first so panics in the goroutines started below are reported
+	if err := m.openReporter(); err != nil {
+		return err
+	}
+
+	// before the db is opened so it traces with the exporting provider
+	tracerProvider, err := openTracing(m.Config.Tracing)
+	if err != nil {
+		return err
+	}
+	m.TracerProvider = tracerProvider
 
-	if err := m.DB.Open(); err != nil {
-		return fmt.Errorf("cannot open db: %w", err)
+	db, err := openDB(m.Config, false)
+	if err != nil {
+		return err
 	}
+	m.DB = db
+	prometheus.MustRegister(postgres.NewStatsCollector(m.DB))
 
+	key := []byte(m.Config.Auth.Secret)
+	if len(key) == 0 {
+		log.Warn().Msg("no token secret set with -secret, FRS_SECRET or auth.secret, access tokens will not survive a restart")
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+	}
+
+	authService := postgres.NewAuthService(m.DB, key)
+	apiKeyService := postgres.NewAPIKeyService(m.DB)
+	sessionService := postgres.NewSessionService(m.DB)
 	userService := postgres.NewUserService(m.DB)
 	fundRaiserService := postgres.NewFundRaiserService(m.DB)
 
 	// attach underlying services to http server
+	m.HttpServer.AuthService = authService
+	m.HttpServer.APIKeyService = apiKeyService
+	m.HttpServer.SessionService = sessionService
+
+	switch m.Config.Auth.ThrottleStore {
+	case "memory":
+		m.HttpServer.LoginThrottle = frs.NewLoginThrottle(inmem.NewLoginAttemptStore())
+	case "postgres":
+		m.HttpServer.LoginThrottle = frs.NewLoginThrottle(postgres.NewLoginAttemptStore(m.DB))
+	default:
+		return fmt.Errorf("unknown throttle store %q", m.Config.Auth.ThrottleStore)
+	}
 	m.HttpServer.UserService = userService
+	m.HttpServer.ReadyChecks = map[string]func(context.Context) error{
+		"database":   m.DB.Ping,
+		"migrations": m.DB.CheckMigrations,
+	}
+	m.HttpServer.WorkerStatus = m.DB.Workers
 	m.HttpServer.FundRaiserService = fundRaiserService
 
+	providers, err := loadOIDCProviders(m.Config.Auth.OIDCProviders)
+	if err != nil {
+		return err
+	}
+	m.HttpServer.OIDCProviders = providers
+
 	if err := m.HttpServer.Open(); err != nil {
 		return fmt.Errorf("cannot start server: %w", err)
 	}
 
-	fmt.Printf("running: url=%q dsn=%q\n", m.HttpServer.Url(), dsn)
+	fmt.Printf("running: url=%q dsn=%q\n", m.HttpServer.Url(), m.Config.Redacted().DB.DSN)
+	if adminUrl := m.HttpServer.AdminUrl(); adminUrl != "" {
+		fmt.Printf("metrics: url=%q\n", adminUrl+"/metrics")
+	}
 
 	return nil
 
 }
 
-func (m *Main) close() error {
-	if err := m.DB.Close(); err != nil {
-		return fmt.Errorf("closing db error: %w", err)
+func (m *Main) openReporter() error {
+	var reporters report.Multi
+
+	if dsn := m.Config.Report.SentryDSN; dsn != "" {
+		sentry, err := report.NewSentryReporter(dsn)
+		if err != nil {
+			return err
+		}
+		sentry.Environment = m.Config.Report.Environment
+		sentry.ServerName, _ = os.Hostname()
+		reporters = append(reporters, sentry)
+	}
+
+	if path := m.Config.Report.File; path != "" {
+		file, err := report.OpenFileReporter(path)
+		if err != nil {
+			return err
+		}
+		m.ReportFile = file
+		reporters = append(reporters, file)
+	}
+
+	if len(reporters) > 0 {
+		m.ReportQueue = report.NewQueue(reporters, report.DefaultQueueSize)
+		frs.DefaultPanicReporter = m.ReportQueue
+	}
+	return nil
+}
+
+func loadOIDCProviders(path string) (map[string]*oidc.Provider, error) {
+	providers := make(map[string]*oidc.Provider)
+	if path == "" {
+		return providers, nil
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read oidc providers: %w", err)
 	}
+
+	var configs []oidc.Config
+	if err := json.Unmarshal(buf, &configs); err != nil {
+		return nil, fmt.Errorf("cannot parse oidc providers: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, config := range configs {
+		provider, err := oidc.NewProvider(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		providers[config.Name] = provider
+	}
+	return providers, nil
+}
+
+// close stops the server before the db so in-flight requests can finish.
+func (m *Main) close() error {
 	if err := m.HttpServer.Close(); err != nil {
 		return fmt.Errorf("error closinng server: %w", err)
 	}
+	if m.DB != nil {
+		if err := m.DB.Close(); err != nil {
+			return fmt.Errorf("closing db error: %w", err)
+		}
+	}
+	if m.ReportQueue != nil {
+		// sends what is still queued, the file is closed right after
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := m.ReportQueue.Close(ctx); err != nil {
+			return fmt.Errorf("closing report queue error: %w", err)
+		}
+	}
+	if m.ReportFile != nil {
+		if err := m.ReportFile.Close(); err != nil {
+			return fmt.Errorf("closing report file error: %w", err)
+		}
+	}
+	if m.TracerProvider != nil {
+		// flushes spans still queued for export
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := m.TracerProvider.Shutdown(ctx); err != nil {
+			return fmt.Errorf("closing tracer provider error: %w", err)
+		}
+	}
 	return nil
 }
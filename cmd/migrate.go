@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TezzBhandari/frs/postgres"
+)
+
+const migrateUsage = `usage: frs migrate <command> [flags]
+
+commands:
+  up          apply every pending migration
+  down [n]    roll back the last n migrations, defaults to 1
+  status      list migrations and whether they are applied
+  redo        roll back the last migration and apply it again`
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return usageError(migrateUsage)
+	}
+
+	command := args[0]
+	switch command {
+	case "up", "down", "status", "redo":
+	default:
+		return usageError(fmt.Sprintf("unknown migrate command %q\n%s", command, migrateUsage))
+	}
+
+	fs := flag.NewFlagSet("migrate "+command, flag.ExitOnError)
+	config, err := loadConfig(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(config, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		return db.MigrateUp(ctx)
+	case "down":
+		steps := 1
+		if fs.NArg() > 0 {
+			n, err := strconv.Atoi(fs.Arg(0))
+			if err != nil || n < 1 {
+				return fmt.Errorf("migrate down: %q is not a positive number", fs.Arg(0))
+			}
+			steps = n
+		}
+		return db.MigrateDown(ctx, steps)
+	case "redo":
+		return db.MigrateRedo(ctx)
+	case "status":
+		statuses, err := db.MigrationStatus(ctx)
+		if errors.Is(err, postgres.ErrMigrationTableMissing) {
+			fmt.Fprintln(os.Stderr, err)
+		} else if err != nil {
+			return err
+		}
+		return printMigrationStatus(statuses)
+	}
+	return nil
+}
+
+func printMigrationStatus(statuses []*postgres.MigrationStatus) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		status, appliedAt := "pending", ""
+		if s.AppliedAt != nil {
+			status, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		switch {
+		case s.Missing:
+			status = "missing"
+		case s.Modified:
+			status = "modified"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/postgres"
+	"github.com/rs/zerolog/log"
+)
+
+// demo data for local development, seeding twice is a no-op
+var (
+	seedUsers = []*frs.User{
+		{Username: "organizer", Email: "organizer@example.com", Role: frs.RoleOrganizer},
+		{Username: "donor", Email: "donor@example.com", Role: frs.RoleDonor},
+	}
+
+	seedFundRaisers = []*frs.FundRaiser{
+		{Title: "Clean water for Dhading", Story: "Help us build two wells so the village no longer walks an hour for water.", CoverImg: "https://picsum.photos/seed/water/800/400", TargetAmount: 5000},
+		{Title: "School books for Bardiya", Story: "Every child in the school deserves their own set of text books.", CoverImg: "https://picsum.photos/seed/books/800/400", TargetAmount: 1200},
+		{Title: "Animal shelter roof", Story: "The monsoon destroyed the roof of the shelter, the dogs need a dry place.", CoverImg: "https://picsum.photos/seed/shelter/800/400", TargetAmount: 3000},
+	}
+)
+
+func runSeed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	password := fs.String("password", "password123", "Sets the password of the demo users")
+	config, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(config, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := systemContext()
+	userService := postgres.NewUserService(db)
+	fundRaiserService := postgres.NewFundRaiserService(db)
+
+	for _, user := range seedUsers {
+		user.Password = *password
+		if err := userService.CreateUser(ctx, user); frs.ErrorCode(err) == frs.ECONFLICT {
+			log.Info().Msg("database already seeded")
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("seed user %q: %w", user.Username, err)
+		}
+	}
+
+	// fund raisers are owned by whoever creates them
+	organizerCtx := frs.NewContextWithUser(ctx, seedUsers[0])
+	for i, fundRaiser := range seedFundRaisers {
+		endsAt := time.Now().UTC().AddDate(0, 1+i, 0).Truncate(24 * time.Hour)
+		fundRaiser.EndsAt = &endsAt
+		if err := fundRaiserService.CreateFundRaiser(organizerCtx, fundRaiser); err != nil {
+			return fmt.Errorf("seed fund raiser %q: %w", fundRaiser.Title, err)
+		}
+	}
+
+	fmt.Printf("seeded %d users and %d fund raisers\n", len(seedUsers), len(seedFundRaisers))
+	return nil
+}
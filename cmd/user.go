@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/postgres"
+)
+
+const userUsage = `usage: frs user <command> [flags]
+
+commands:
+  create-admin     create an admin account
+  reset-password   set a new password and log the user out everywhere
+
+the password is read from stdin when -password is not set`
+
+func runUser(args []string) error {
+	if len(args) == 0 {
+		return usageError(userUsage)
+	}
+
+	switch args[0] {
+	case "create-admin":
+		return runCreateAdmin(args[1:])
+	case "reset-password":
+		return runResetPassword(args[1:])
+	default:
+		return usageError(fmt.Sprintf("unknown user command %q\n%s", args[0], userUsage))
+	}
+}
+
+func runCreateAdmin(args []string) error {
+	fs := flag.NewFlagSet("user create-admin", flag.ExitOnError)
+	username := fs.String("username", "", "Sets the username of the admin")
+	email := fs.String("email", "", "Sets the email of the admin")
+	password := fs.String("password", "", "Sets the password of the admin")
+	config, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(config, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	user := &frs.User{
+		Username: *username,
+		Email:    *email,
+		Role:     frs.RoleAdmin,
+	}
+	if user.Password, err = readPassword(*password); err != nil {
+		return err
+	}
+
+	if err := postgres.NewUserService(db).CreateUser(systemContext(), user); err != nil {
+		return err
+	}
+	fmt.Printf("created admin %q with id %d\n", user.Username, user.ID)
+	return nil
+}
+
+func runResetPassword(args []string) error {
+	fs := flag.NewFlagSet("user reset-password", flag.ExitOnError)
+	id := fs.Int64("id", 0, "Sets the id of the user")
+	email := fs.String("email", "", "Sets the email of the user, used when -id is not set")
+	password := fs.String("password", "", "Sets the new password")
+	config, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *id == 0 && *email == "" {
+		return fmt.Errorf("set -id or -email flag")
+	}
+
+	db, err := openDB(config, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := systemContext()
+	userService := postgres.NewUserService(db)
+
+	if *id == 0 {
+		users, _, err := userService.FindUsers(ctx, &frs.FilterUser{Email: email, Limit: 1})
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("no user with email %q", *email)
+		}
+		*id = users[0].ID
+	}
+
+	newPassword, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	if err := userService.SetPassword(ctx, *id, newPassword); err != nil {
+		return err
+	}
+	fmt.Printf("password of user %d reset, existing sessions revoked\n", *id)
+	return nil
+}
+
+// readPassword returns password or, when it is empty, the first line of
+// stdin so it doesn't end up in the shell history.
+func readPassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, "password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("cannot read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// systemContext acts as an admin. Commands are run by operators with access
+// to the database so they skip the api's authentication.
+func systemContext() context.Context {
+	return frs.NewContextWithUser(context.Background(), &frs.User{Username: "frs", Role: frs.RoleAdmin})
+}
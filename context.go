@@ -0,0 +1,81 @@
+package frs
+
+import "context"
+
+type contextKey int
+
+const (
+	userContextKey contextKey = iota + 1
+	apiKeyContextKey
+	sessionContextKey
+	clientContextKey
+	requestIDContextKey
+)
+
+// NewContextWithUser returns a copy of ctx carrying the authenticated user.
+func NewContextWithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// UserFromContext returns the authenticated user or nil for anonymous requests.
+func UserFromContext(ctx context.Context) *User {
+	user, _ := ctx.Value(userContextKey).(*User)
+	return user
+}
+
+// UserIDFromContext returns the authenticated user's id or zero.
+func UserIDFromContext(ctx context.Context) int64 {
+	if user := UserFromContext(ctx); user != nil {
+		return user.ID
+	}
+	return 0
+}
+
+// NewContextWithAPIKey returns a copy of ctx carrying the api key the request
+// was authenticated with.
+func NewContextWithAPIKey(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey, key)
+}
+
+// APIKeyFromContext returns the api key used to authenticate or nil.
+func APIKeyFromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(apiKeyContextKey).(*APIKey)
+	return key
+}
+
+// NewContextWithSession returns a copy of ctx carrying the session the
+// request was authenticated with.
+func NewContextWithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey, session)
+}
+
+// SessionFromContext returns the current session or nil.
+func SessionFromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionContextKey).(*Session)
+	return session
+}
+
+// NewContextWithClient returns a copy of ctx carrying the request's origin.
+func NewContextWithClient(ctx context.Context, client *Client) context.Context {
+	return context.WithValue(ctx, clientContextKey, client)
+}
+
+// ClientFromContext returns the request's origin, empty when unknown.
+func ClientFromContext(ctx context.Context) *Client {
+	if client, ok := ctx.Value(clientContextKey).(*Client); ok {
+		return client
+	}
+	return &Client{}
+}
+
+// NewContextWithRequestID returns a copy of ctx carrying the id correlating
+// the logs of one request.
+func NewContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// RequestIDFromContext returns the request id, empty outside of a request.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
@@ -0,0 +1,48 @@
+package frs
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor marks the last row of a page in (created_at, id) order. Listing
+// from a cursor stays stable while new rows are inserted, unlike offsets.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// Encode returns the opaque form handed to clients.
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d.%d", c.CreatedAt.UnixMicro(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor returns EINVALID Error if s was not produced by Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	invalid := Errorf(EINVALID, "invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return nil, invalid
+	}
+
+	micro, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+
+	c := &Cursor{CreatedAt: time.UnixMicro(micro).UTC()}
+	if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return nil, invalid
+	}
+	return c, nil
+}
@@ -0,0 +1,26 @@
+package frs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+)
+
+func TestCursor(t *testing.T) {
+	want := frs.Cursor{CreatedAt: time.Date(2024, 5, 1, 10, 30, 0, 123000, time.UTC), ID: 1786543210987654321}
+
+	got, err := frs.DecodeCursor(want.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+
+	for _, s := range []string{"", "not base64!", "MTIz"} {
+		if _, err := frs.DecodeCursor(s); frs.ErrorCode(err) != frs.EINVALID {
+			t.Errorf("%q: got: %v, want: %q", s, err, frs.EINVALID)
+		}
+	}
+}
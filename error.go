@@ -3,19 +3,45 @@ package frs
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 const (
 	EBADREQUEST   = "bad_request"
 	EINVALID      = "invalid"
 	EUNAUTHORIZED = "unauthorized"
+	EFORBIDDEN    = "forbidden"
+	ERATELIMIT    = "rate_limited"
 	ENOTFOUND     = "not_found"
-	EINTERNAL     = "internal_error"
+	ECONFLICT     = "conflict"
+	// the resource changed since the client last read it
+	EPRECONDITION = "precondition_failed"
+	// a conditional request header such as If-Match is missing
+	EPRECONDITIONREQUIRED = "precondition_required"
+	EINTERNAL             = "internal_error"
+)
+
+// field violation codes
+const (
+	FieldRequired = "required"
+	FieldInvalid  = "invalid"
+	FieldTooShort = "too_short"
+	// the value is unique and already in use
+	FieldTaken = "taken"
 )
 
 type Error struct {
 	Code    string
 	Message string
+	// set on validation errors, one entry per invalid field
+	Fields []FieldError
+}
+
+// FieldError describes why a single field of a request was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
 
 func (e *Error) Error() string {
@@ -54,3 +80,38 @@ func Errorf(code string, format string, args ...any) *Error {
 		Message: fmt.Sprintf(format, args...),
 	}
 }
+
+func ErrorFields(err error) []FieldError {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Fields
+	}
+	return nil
+}
+
+// validation collects every field violation so clients can show them all at
+// once instead of fixing one field per round trip.
+type validation struct {
+	fields []FieldError
+}
+
+func (v *validation) add(field, code, format string, args ...any) {
+	v.fields = append(v.fields, FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns an EINVALID Error listing the violations or nil if there are none.
+func (v *validation) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(v.fields))
+	for i, f := range v.fields {
+		messages[i] = f.Message
+	}
+	return &Error{
+		Code:    EINVALID,
+		Message: strings.Join(messages, "; "),
+		Fields:  v.fields,
+	}
+}
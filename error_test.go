@@ -0,0 +1,49 @@
+package frs_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/TezzBhandari/frs"
+)
+
+func TestUserValidate(t *testing.T) {
+	u := &frs.User{Email: "not-an-email", Password: "short"}
+	err := u.Validate()
+	if got := frs.ErrorCode(err); got != frs.EINVALID {
+		t.Fatalf("code: got: %q, want: %q", got, frs.EINVALID)
+	}
+
+	want := []frs.FieldError{
+		{Field: "username", Code: frs.FieldRequired, Message: "username required"},
+		{Field: "email", Code: frs.FieldInvalid, Message: "invalid email"},
+		{Field: "password", Code: frs.FieldTooShort, Message: "password should be at least 8 character long"},
+	}
+	if got := frs.ErrorFields(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields: got: %+v, want: %+v", got, want)
+	}
+
+	u = &frs.User{Username: "jane", Email: "jane@example.com", Password: "password"}
+	if err := u.Validate(); err != nil {
+		t.Errorf("valid user: got: %v", err)
+	}
+}
+
+func TestFundRaiserValidate(t *testing.T) {
+	fr := &frs.FundRaiser{Story: "clean water for everyone"}
+	fields := frs.ErrorFields(fr.Validate())
+
+	got := make([]string, len(fields))
+	for i, f := range fields {
+		got[i] = f.Field
+	}
+	if want := []string{"title", "cover_img", "target_amount"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fields: got: %v, want: %v", got, want)
+	}
+}
+
+func TestErrorFields_NotValidation(t *testing.T) {
+	if fields := frs.ErrorFields(frs.Errorf(frs.ENOTFOUND, "missing")); fields != nil {
+		t.Errorf("got: %+v, want: nil", fields)
+	}
+}
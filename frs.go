@@ -1,5 +1,4 @@
 package frs
 
-func ReportPanic(err any) {
-
-}
+// DefaultLimit is the page size used when a filter does not set a limit.
+const DefaultLimit = 10
@@ -6,56 +6,154 @@ import (
 )
 
 type FundRaiser struct {
-	ID           int64     `json:"id"`
-	Title        string    `json:"title"`
-	Story        string    `json:"story"`
-	CoverImg     string    `json:"cover_img"`
-	TargetAmount float64   `json:"target_amount"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int64      `json:"id"`
+	Title        string     `json:"title"`
+	Story        string     `json:"story"`
+	CoverImg     string     `json:"cover_img"`
+	TargetAmount float64    `json:"target_amount"`
+	AmountRaised float64    `json:"amount_raised"` // read only, always 0 until donations are recorded
+	EndsAt       *time.Time `json:"ends_at"`
+	OrganizerID  int64      `json:"organizer_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	// incremented on every update, used for optimistic concurrency control
+	Version int `json:"version"`
+
+	// only set on full text search results, html escaped with matches
+	// wrapped in <mark> tags
+	Snippet string  `json:"snippet,omitempty"`
+	Rank    float64 `json:"rank,omitempty"`
 }
 
+// sort keys accepted by FilterFundRaiser.Sort
+const (
+	SortCreatedAt    = "created_at"
+	SortTargetAmount = "target_amount"
+	SortEndingSoon   = "ending_soon"
+	// best full text match first, only valid together with Query
+	SortRelevance = "relevance"
+)
+
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
 type FilterFundRaiser struct {
 	ID        *int64     `json:"id"`
+	IDs       []int64    `json:"ids"`
 	Title     *string    `json:"title"`
 	CreatedAt *time.Time `json:"created_at"`
+	// full text search over title and story, web search syntax
+	Query *string `json:"query"`
+
+	CreatedAfter  *time.Time `json:"created_after"`
+	CreatedBefore *time.Time `json:"created_before"`
+	TargetMin     *float64   `json:"target_min"`
+	TargetMax     *float64   `json:"target_max"`
+
+	// one of the Sort* keys, defaults to SortRelevance when searching and
+	// SortCreatedAt otherwise
+	Sort string `json:"sort"`
+	// SortAsc or SortDesc, defaults to ascending for SortEndingSoon and
+	// descending for everything else
+	Order string `json:"order"`
 
 	Limit  int `json:"limit"`
 	Offset int `json:"offset"`
+	// continue after the row the cursor was issued for, can't be combined
+	// with Offset
+	Cursor string `json:"cursor"`
 }
 
 type UpdateFundRaiser struct {
-	Title        *string  `json:"title"`
-	Story        *string  `json:"story"`
-	CoverImg     *string  `json:"cover_img"`
-	TargetAmount *float64 `json:"target_amount"`
+	Title        *string    `json:"title"`
+	Story        *string    `json:"story"`
+	CoverImg     *string    `json:"cover_img"`
+	TargetAmount *float64   `json:"target_amount"`
+	EndsAt       *time.Time `json:"ends_at"`
+	// version the update is based on, nil skips the check
+	Version *int `json:"version"`
 }
 
 type FundRaiserService interface {
 	CreateFundRaiser(ctx context.Context, FundRaiser *FundRaiser) error
+	// returns one page of matches and the total number of matches
 	FindFundRaiser(ctx context.Context, filterFundRaiser *FilterFundRaiser) ([]*FundRaiser, int, error)
 	FindFundRaiserById(ctx context.Context, id int64) (*FundRaiser, error)
 	UpdateFundRaiser(ctx context.Context, id int64, updFundRaiser *UpdateFundRaiser) (*FundRaiser, error)
-	DeleteFundRaiser(ctx context.Context, id int64) error
+	// ReplaceFundRaiser overwrites every writable field with those of
+	// fundRaiser, zero values included. fundRaiser.Version must match the
+	// stored version unless it is zero.
+	// return NOTFOUND | INVALID | FORBIDDEN | PRECONDITION Error
+	ReplaceFundRaiser(ctx context.Context, id int64, fundRaiser *FundRaiser) (*FundRaiser, error)
+	// version must match the stored version unless it is zero
+	// return NOTFOUND | FORBIDDEN | PRECONDITION Error
+	DeleteFundRaiser(ctx context.Context, id int64, version int) error
 }
 
 func (fr *FundRaiser) Validate() error {
+	v := &validation{}
+
 	if fr.Title == "" {
-		return Errorf(EBADREQUEST, "fund raiser title is required")
+		v.add("title", FieldRequired, "fund raiser title is required")
 	}
 
 	if fr.Story == "" {
-		return Errorf(EBADREQUEST, "fund raiser story is required")
+		v.add("story", FieldRequired, "fund raiser story is required")
 	}
 
 	if fr.CoverImg == "" {
-		return Errorf(EBADREQUEST, "fund raiser cover image is required")
+		v.add("cover_img", FieldRequired, "fund raiser cover image is required")
 	}
 
 	if fr.TargetAmount == 0.0 {
-		return Errorf(EBADREQUEST, "fund raiser target amount is required")
+		v.add("target_amount", FieldRequired, "fund raiser target amount is required")
 	}
 
-	return nil
+	return v.err()
+}
+
+// SortKey returns the sort key in effect once defaults are applied.
+func (f *FilterFundRaiser) SortKey() string {
+	switch {
+	case f.Sort != "":
+		return f.Sort
+	case f.Query != nil:
+		return SortRelevance
+	default:
+		return SortCreatedAt
+	}
+}
+
+func (f *FilterFundRaiser) Validate() error {
+	switch f.Sort {
+	case "", SortCreatedAt, SortTargetAmount, SortEndingSoon:
+	case SortRelevance:
+		if f.Query == nil {
+			return Errorf(EINVALID, "sort %q requires a search query", f.Sort)
+		}
+	default:
+		return Errorf(EINVALID, "invalid sort %q", f.Sort)
+	}
+
+	switch f.Order {
+	case "", SortAsc, SortDesc:
+	default:
+		return Errorf(EINVALID, "invalid order %q", f.Order)
+	}
+
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		return Errorf(EINVALID, "created_after must be before created_before")
+	}
+
+	if f.TargetMin != nil && f.TargetMax != nil && *f.TargetMin > *f.TargetMax {
+		return Errorf(EINVALID, "target_min must not be greater than target_max")
+	}
 
+	if f.Limit < 0 || f.Offset < 0 {
+		return Errorf(EINVALID, "limit and offset must not be negative")
+	}
+
+	return nil
 }
@@ -0,0 +1,12 @@
+package frs
+
+import "time"
+
+// WorkerStatus describes a background goroutine on the readiness report.
+type WorkerStatus struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	// last time the worker finished its job successfully
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/utils"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+func (s *Server) registerAPIKeyRoutes(r *mux.Router) {
+	r.HandleFunc("/users/{id}/api-keys", s.handleCreateAPIKey).Methods(http.MethodPost)
+	r.HandleFunc("/users/{id}/api-keys", s.handleFindAPIKeys).Methods(http.MethodGet)
+	r.HandleFunc("/users/{id}/api-keys/{keyId}", s.handleRevokeAPIKey).Methods(http.MethodDelete)
+}
+
+func (s *Server) handleCreateAPIKey(rw http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.ParseInt(mux.Vars(r)["id"], 0, 64)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EINVALID, utils.InvalidUserIdMsg()))
+		return
+	}
+
+	key := &frs.APIKey{}
+	if err := ReadJsonBody(r.Body, key); err != nil {
+		Error(rw, r, err)
+		return
+	}
+	key.UserID = userId
+
+	if err := s.APIKeyService.CreateAPIKey(r.Context(), key); err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(rw).Encode(SuccessResponse{
+		Data: map[string]any{
+			"api_key": key,
+		},
+	}); err != nil {
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+	}
+}
+
+func (s *Server) handleFindAPIKeys(rw http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.ParseInt(mux.Vars(r)["id"], 0, 64)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EINVALID, utils.InvalidUserIdMsg()))
+		return
+	}
+
+	keys, err := s.APIKeyService.FindAPIKeys(r.Context(), userId)
+	if err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(SuccessResponse{
+		Data: map[string]any{
+			"api_keys": keys,
+		},
+	}); err != nil {
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+	}
+}
+
+func (s *Server) handleRevokeAPIKey(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userId, err := strconv.ParseInt(vars["id"], 0, 64)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EINVALID, utils.InvalidUserIdMsg()))
+		return
+	}
+
+	keyId, err := strconv.ParseInt(vars["keyId"], 0, 64)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EINVALID, utils.InvalidAPIKeyIdMsg()))
+		return
+	}
+
+	if err := s.APIKeyService.RevokeAPIKey(r.Context(), userId, keyId); err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
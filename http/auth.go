@@ -0,0 +1,131 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/utils"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+func (s *Server) registerAuthRoutes(r *mux.Router) {
+	r.HandleFunc("/auth/login", s.handleLogin).Methods(http.MethodPost)
+}
+
+func (s *Server) handleLogin(rw http.ResponseWriter, r *http.Request) {
+	creds := &frs.Credentials{}
+	if err := ReadJsonBody(r.Body, creds); err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	ip := remoteIP(r)
+	if s.LoginThrottle != nil {
+		if retryAfter, err := s.LoginThrottle.Allow(r.Context(), creds.Email, ip); err != nil {
+			if retryAfter > 0 {
+				rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			}
+			Error(rw, r, err)
+			return
+		}
+	}
+
+	auth, err := s.AuthService.Login(r.Context(), creds)
+	if err != nil {
+		if s.LoginThrottle != nil && frs.ErrorCode(err) == frs.EUNAUTHORIZED {
+			if err := s.LoginThrottle.Fail(r.Context(), creds.Email, ip); err != nil {
+				log.Ctx(r.Context()).Error().Err(err).Msg("cannot record failed login")
+			}
+		}
+		Error(rw, r, err)
+		return
+	}
+
+	if s.LoginThrottle != nil {
+		if err := s.LoginThrottle.Succeed(r.Context(), creds.Email); err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("cannot reset failed logins")
+		}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(SuccessResponse{
+		Data: map[string]any{
+			"auth": auth,
+		},
+	}); err != nil {
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+	}
+}
+
+// authenticate attaches the user owning the bearer token or api key to the
+// request context. Requests without credentials continue anonymously.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(frs.NewContextWithClient(r.Context(), &frs.Client{
+			IP:        remoteIP(r),
+			UserAgent: r.UserAgent(),
+		}))
+
+		if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+			user, key, err := s.APIKeyService.AuthenticateAPIKey(r.Context(), apiKey)
+			if err != nil {
+				Error(rw, r, err)
+				return
+			}
+
+			ctx := frs.NewContextWithAPIKey(frs.NewContextWithUser(r.Context(), user), key)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+			return
+		}
+
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			Error(rw, r, frs.Errorf(frs.EUNAUTHORIZED, "invalid authorization header"))
+			return
+		}
+
+		user, session, err := s.AuthService.Authenticate(r.Context(), token)
+		if err != nil {
+			Error(rw, r, err)
+			return
+		}
+
+		ctx := frs.NewContextWithSession(frs.NewContextWithUser(r.Context(), user), session)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// remoteIP returns the address of the connecting peer. Forwarded headers are
+// ignored as they are trivially spoofed without a trusted proxy in front.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// requirePermission rejects requests whose user does not hold perm.
+func requirePermission(perm frs.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if err := frs.Authorize(r.Context(), perm); err != nil {
+			Error(rw, r, err)
+			return
+		}
+		next(rw, r)
+	}
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TezzBhandari/frs"
+)
+
+// etag formats a resource version as a strong entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion returns the version named by the If-Match header. Writes
+// must be conditional so a missing header is rejected, "*" matches any
+// version and is returned as zero.
+func ifMatchVersion(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, frs.Errorf(frs.EPRECONDITIONREQUIRED, "If-Match header required, send the ETag of the version being changed")
+	}
+	if value == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(value, `"`))
+	if err != nil || version <= 0 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return 0, frs.Errorf(frs.EBADREQUEST, "invalid If-Match header, expected a single ETag")
+	}
+	return version, nil
+}
+
+// optionalIfMatchVersion is ifMatchVersion for endpoints that took writes
+// before conditional requests existed, a missing header matches any version.
+func optionalIfMatchVersion(r *http.Request) (int, error) {
+	if r.Header.Get("If-Match") == "" {
+		return 0, nil
+	}
+	return ifMatchVersion(r)
+}
@@ -0,0 +1,135 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/TezzBhandari/frs"
+)
+
+func TestFindFundRaiserById_ETag(t *testing.T) {
+	s, service := newFundRaiserServer(t)
+	service.current = &frs.FundRaiser{ID: 7, Version: 3}
+
+	resp, err := http.Get(s.Url() + "/api/v1/fund-raiser/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("ETag"); got != `"3"` {
+		t.Errorf("ETag: got: %q, want: %q", got, `"3"`)
+	}
+}
+
+func TestUpdateFundRaiser_IfMatch(t *testing.T) {
+	s, service := newFundRaiserServer(t)
+	service.current = &frs.FundRaiser{ID: 7, Version: 3}
+	body := `{"title": "Clean water", "story": "Wells", "cover_img": "well.png", "target_amount": 500}`
+
+	for _, tt := range []struct {
+		method  string
+		ifMatch string
+		status  int
+	}{
+		{http.MethodPut, "", http.StatusPreconditionRequired},
+		{http.MethodPut, "3", http.StatusBadRequest},
+		{http.MethodPut, `"2"`, http.StatusPreconditionFailed},
+		{http.MethodPatch, `"2"`, http.StatusPreconditionFailed},
+		{http.MethodPatch, "", http.StatusPreconditionRequired},
+		{http.MethodPut, `"3"`, http.StatusOK},
+	} {
+		req, _ := http.NewRequest(tt.method, s.Url()+"/api/v1/fund-raiser/7", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s If-Match %q: got: %d, want: %d", tt.method, tt.ifMatch, resp.StatusCode, tt.status)
+		}
+		if tt.status == http.StatusOK && resp.Header.Get("ETag") != `"4"` {
+			t.Errorf("%s ETag: got: %q, want: %q", tt.method, resp.Header.Get("ETag"), `"4"`)
+		}
+	}
+}
+
+type userService struct {
+	frs.UserService
+	current  *frs.User
+	replaced *frs.User
+}
+
+func (s *userService) FindUserById(ctx context.Context, id int64) (*frs.User, error) {
+	return s.current, nil
+}
+
+func (s *userService) ReplaceUser(ctx context.Context, id int64, user *frs.User) (*frs.User, error) {
+	s.replaced = user
+	updated := *user
+	updated.Version++
+	return &updated, nil
+}
+
+func TestUpdateUser_IfMatch(t *testing.T) {
+	s, _ := newFundRaiserServer(t)
+	service := &userService{current: &frs.User{ID: 7, Username: "jane", Email: "jane@example.com", Version: 3}}
+	s.UserService = service
+
+	for _, tt := range []struct {
+		method  string
+		ifMatch string
+		status  int
+		version int
+	}{
+		// user writes predate If-Match, it is optional
+		{http.MethodPatch, "", http.StatusOK, 3},
+		{http.MethodPatch, `"2"`, http.StatusPreconditionFailed, 0},
+		{http.MethodPatch, `"3"`, http.StatusOK, 3},
+		{http.MethodPut, "", http.StatusOK, 0},
+		{http.MethodPut, `"2"`, http.StatusOK, 2},
+		{http.MethodPut, "2", http.StatusBadRequest, 0},
+	} {
+		service.replaced = nil
+		req, _ := http.NewRequest(tt.method, s.Url()+"/api/v1/users/7", strings.NewReader(`{"username": "janet", "email": "jane@example.com"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s If-Match %q: got: %d, want: %d", tt.method, tt.ifMatch, resp.StatusCode, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			if service.replaced != nil {
+				t.Errorf("%s If-Match %q: replaced after a failed precondition", tt.method, tt.ifMatch)
+			}
+			continue
+		}
+		// PATCH always pins the version it read so a concurrent write is
+		// caught instead of overwritten
+		if service.replaced.Version != tt.version {
+			t.Errorf("%s If-Match %q: got: version %d, want: %d", tt.method, tt.ifMatch, service.replaced.Version, tt.version)
+		}
+		if want := etag(tt.version + 1); resp.Header.Get("ETag") != want {
+			t.Errorf("%s If-Match %q: ETag: got: %q, want: %q", tt.method, tt.ifMatch, resp.Header.Get("ETag"), want)
+		}
+	}
+}
+
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
@@ -18,6 +18,7 @@ func (s *Server) registerFundRaiserRoutes(r *mux.Router) {
 	r.HandleFunc("/fund-raiser/{id}", s.handleFindFundRaiserById).Methods(http.MethodGet)
 	r.HandleFunc("/fund-raiser/{id}", s.handleDeleteFundRaiser).Methods(http.MethodDelete)
 	r.HandleFunc("/fund-raiser/{id}", s.handleUpdateFundRaiser).Methods(http.MethodPut)
+	r.HandleFunc("/fund-raiser/{id}", s.handlePatchFundRaiser).Methods(http.MethodPatch)
 }
 
 func (s *Server) handleCreateFundRaiser(rw http.ResponseWriter, r *http.Request) {
@@ -37,18 +38,32 @@ func (s *Server) handleCreateFundRaiser(rw http.ResponseWriter, r *http.Request)
 }
 
 func (s *Server) handleFindFundRaiser(rw http.ResponseWriter, r *http.Request) {
-	filterFundRaiser := &frs.FilterFundRaiser{}
-	if err := ReadJsonBody(r.Body, filterFundRaiser); err != nil {
+	filterFundRaiser, err := parseFilterFundRaiser(rw, r)
+	if err != nil {
 		Error(rw, r, err)
 		return
 	}
 
-	fundRaisers, _, err := s.FundRaiserService.FindFundRaiser(r.Context(), filterFundRaiser)
+	fundRaisers, total, err := s.FundRaiserService.FindFundRaiser(r.Context(), filterFundRaiser)
 	if err != nil {
 		Error(rw, r, err)
 		return
 	}
 
+	p := page{
+		total:  total,
+		limit:  filterFundRaiser.Limit,
+		offset: filterFundRaiser.Offset,
+		cursor: filterFundRaiser.Cursor,
+		size:   len(fundRaisers),
+	}
+	// cursors are keyed on created_at, other orders page by offset only
+	sortedByCreatedAt := filterFundRaiser.SortKey() == frs.SortCreatedAt
+	if len(fundRaisers) > 0 && sortedByCreatedAt {
+		last := fundRaisers[len(fundRaisers)-1]
+		p.last = &frs.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
 
@@ -56,8 +71,9 @@ func (s *Server) handleFindFundRaiser(rw http.ResponseWriter, r *http.Request) {
 		Data: map[string]any{
 			"fundraisers": fundRaisers,
 		},
+		Meta: newMeta(r, p),
 	}); err != nil {
-		log.Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
 	}
 
 }
@@ -74,6 +90,7 @@ func (s *Server) handleFindFundRaiserById(rw http.ResponseWriter, r *http.Reques
 		Error(rw, r, err)
 		return
 	}
+	rw.Header().Set("ETag", etag(fundRaiser.Version))
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(rw).Encode(SuccessResponse{
@@ -81,7 +98,7 @@ func (s *Server) handleFindFundRaiserById(rw http.ResponseWriter, r *http.Reques
 			"fund-raiser": fundRaiser,
 		},
 	}); err != nil {
-		log.Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
 	}
 }
 
@@ -92,7 +109,12 @@ func (s *Server) handleDeleteFundRaiser(rw http.ResponseWriter, r *http.Request)
 		Error(rw, r, frs.Errorf(frs.EINVALID, utils.InvalidFundRaiserIdMsg()))
 		return
 	}
-	err = s.FundRaiserService.DeleteFundRaiser(r.Context(), fundRaiserId)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		Error(rw, r, err)
+		return
+	}
+	err = s.FundRaiserService.DeleteFundRaiser(r.Context(), fundRaiserId, version)
 	if err != nil {
 		Error(rw, r, err)
 		return
@@ -109,17 +131,65 @@ func (s *Server) handleUpdateFundRaiser(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	updFundRaiser := &frs.UpdateFundRaiser{}
-	if err := ReadJsonBody(r.Body, updFundRaiser); err != nil {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	// PUT replaces the whole resource, omitted fields are cleared
+	fundRaiser := &frs.FundRaiser{}
+	if err := ReadJsonBody(r.Body, fundRaiser); err != nil {
 		Error(rw, r, err)
 		return
 	}
-	updatedFundRaiser, err := s.FundRaiserService.UpdateFundRaiser(r.Context(), fundRaiserId, updFundRaiser)
+	fundRaiser.Version = version
+	s.replaceFundRaiser(rw, r, fundRaiserId, fundRaiser)
+}
+
+func (s *Server) handlePatchFundRaiser(rw http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	fundRaiserId, err := strconv.ParseInt(id, 0, 64)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EINVALID, utils.InvalidFundRaiserIdMsg()))
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	current, err := s.FundRaiserService.FindFundRaiserById(r.Context(), fundRaiserId)
+	if err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	// don't merge into a version the client hasn't seen
+	if version != 0 && version != current.Version {
+		Error(rw, r, frs.Errorf(frs.EPRECONDITION, utils.StaleVersionMsg("fundraiser")))
+		return
+	}
+
+	fundRaiser := &frs.FundRaiser{}
+	if err := applyMergePatch(r, current, fundRaiser); err != nil {
+		Error(rw, r, err)
+		return
+	}
+	fundRaiser.Version = current.Version
+	s.replaceFundRaiser(rw, r, fundRaiserId, fundRaiser)
+}
+
+func (s *Server) replaceFundRaiser(rw http.ResponseWriter, r *http.Request, id int64, fundRaiser *frs.FundRaiser) {
+	updatedFundRaiser, err := s.FundRaiserService.ReplaceFundRaiser(r.Context(), id, fundRaiser)
 	if err != nil {
 		Error(rw, r, err)
 		return
 	}
 
+	rw.Header().Set("ETag", etag(updatedFundRaiser.Version))
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(rw).Encode(SuccessResponse{
@@ -127,7 +197,7 @@ func (s *Server) handleUpdateFundRaiser(rw http.ResponseWriter, r *http.Request)
 			"fund-raiser": updatedFundRaiser,
 		},
 	}); err != nil {
-		log.Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
 	}
 
 }
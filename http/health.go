@@ -0,0 +1,156 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/utils"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// readyCheckTimeout bounds every check so a hung database fails the probe
+// instead of hanging it
+const readyCheckTimeout = 2 * time.Second
+
+// Readiness is the /readyz body.
+type Readiness struct {
+	// ready, not_ready or shutting_down
+	Status  string                 `json:"status"`
+	Checks  map[string]CheckResult `json:"checks,omitempty"`
+	Workers []frs.WorkerStatus     `json:"workers,omitempty"`
+}
+
+type CheckResult struct {
+	// ok or failing
+	Status string `json:"status"`
+	// only served on the admin listener, errors can name hosts and tables
+	Error string `json:"error,omitempty"`
+}
+
+func (s *Server) registerHealthRoutes(r *mux.Router) {
+	r.HandleFunc("/healthz", s.handleHealthz).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", s.handleReadyz).Methods(http.MethodGet)
+}
+
+// handleHealthz only tells the process is alive and serving, dependencies are
+// left to /readyz so a database outage doesn't get every instance restarted.
+func (s *Server) handleHealthz(rw http.ResponseWriter, r *http.Request) {
+	writeHealth(rw, r, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz answers probes on the public listener with statuses only, the
+// errors are logged and served in full on the admin listener.
+func (s *Server) handleReadyz(rw http.ResponseWriter, r *http.Request) {
+	s.writeReadiness(rw, r, false)
+}
+
+func (s *Server) handleAdminReadyz(rw http.ResponseWriter, r *http.Request) {
+	s.writeReadiness(rw, r, true)
+}
+
+func (s *Server) writeReadiness(rw http.ResponseWriter, r *http.Request, detailed bool) {
+	if s.shuttingDown.Load() {
+		writeHealth(rw, r, http.StatusServiceUnavailable, &Readiness{Status: "shutting_down"})
+		return
+	}
+
+	readiness := &Readiness{Status: "ready", Checks: s.runReadyChecks(r.Context())}
+	for _, result := range readiness.Checks {
+		if result.Status != "ok" {
+			readiness.Status = "not_ready"
+		}
+	}
+
+	if s.WorkerStatus != nil {
+		readiness.Workers = s.WorkerStatus()
+		for _, worker := range readiness.Workers {
+			if !worker.Healthy {
+				readiness.Status = "not_ready"
+			}
+		}
+	}
+
+	status := http.StatusOK
+	if readiness.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+
+	if !detailed {
+		logReadiness(r, readiness)
+		readiness = readiness.withoutErrors()
+	}
+	writeHealth(rw, r, status, readiness)
+}
+
+func logReadiness(r *http.Request, readiness *Readiness) {
+	for name, result := range readiness.Checks {
+		if result.Error != "" {
+			log.Ctx(r.Context()).Warn().Str("check", name).Str("error", result.Error).Msg("readiness check failing")
+		}
+	}
+	for _, worker := range readiness.Workers {
+		if worker.Error != "" {
+			log.Ctx(r.Context()).Warn().Str("worker", worker.Name).Str("error", worker.Error).Msg("worker unhealthy")
+		}
+	}
+}
+
+// withoutErrors returns a copy of r for the public listener.
+func (r *Readiness) withoutErrors() *Readiness {
+	public := &Readiness{Status: r.Status}
+	if r.Checks != nil {
+		public.Checks = make(map[string]CheckResult, len(r.Checks))
+		for name, result := range r.Checks {
+			public.Checks[name] = CheckResult{Status: result.Status}
+		}
+	}
+	for _, worker := range r.Workers {
+		worker.Error = ""
+		public.Workers = append(public.Workers, worker)
+	}
+	return public
+}
+
+// runReadyChecks runs the checks concurrently, a probe takes as long as the
+// slowest one.
+func (s *Server) runReadyChecks(ctx context.Context) map[string]CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, readyCheckTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]CheckResult, len(s.ReadyChecks))
+	for name, check := range s.ReadyChecks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+
+			result := CheckResult{Status: "ok"}
+			if err := check(ctx); err != nil {
+				result = CheckResult{Status: "failing", Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = result
+		}(name, check)
+	}
+	wg.Wait()
+	return results
+}
+
+func writeHealth(rw http.ResponseWriter, r *http.Request, status int, body any) {
+	// probes must never see a cached answer
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	if err := json.NewEncoder(rw).Encode(body); err != nil {
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+	}
+}
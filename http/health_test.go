@@ -0,0 +1,124 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	frshttp "github.com/TezzBhandari/frs/http"
+)
+
+func getReadiness(t *testing.T, url string) (int, *frshttp.Readiness) {
+	t.Helper()
+	resp, err := http.Get(url + "/readyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var readiness frshttp.Readiness
+	if err := json.NewDecoder(resp.Body).Decode(&readiness); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, &readiness
+}
+
+func TestHealthz(t *testing.T) {
+	s, _ := newFundRaiserServer(t)
+	s.ReadyChecks = map[string]func(context.Context) error{
+		"database": func(ctx context.Context) error { return errors.New("connection refused") },
+	}
+
+	// alive even when a dependency is down
+	if status, body := get(t, s.Url()+"/healthz"); status != http.StatusOK || body != "{\"status\":\"ok\"}\n" {
+		t.Errorf("got: %d %q", status, body)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	s, _ := newFundRaiserServer(t)
+	s.ReadyChecks = map[string]func(context.Context) error{
+		"database":   func(ctx context.Context) error { return nil },
+		"migrations": func(ctx context.Context) error { return nil },
+	}
+	workers := []frs.WorkerStatus{{Name: "snowflake node lease", Healthy: true}}
+	s.WorkerStatus = func() []frs.WorkerStatus { return workers }
+
+	status, readiness := getReadiness(t, s.Url())
+	if status != http.StatusOK || readiness.Status != "ready" {
+		t.Fatalf("got: %d %+v", status, readiness)
+	}
+	if len(readiness.Checks) != 2 || readiness.Checks["database"].Status != "ok" || len(readiness.Workers) != 1 {
+		t.Errorf("got: %+v", readiness)
+	}
+
+	s.ReadyChecks["migrations"] = func(ctx context.Context) error { return errors.New("2 pending migrations") }
+	status, readiness = getReadiness(t, s.Url())
+	if status != http.StatusServiceUnavailable || readiness.Status != "not_ready" {
+		t.Errorf("failing check: got: %d %s", status, readiness.Status)
+	}
+	// the error is logged, not served to the public
+	if got := readiness.Checks["migrations"]; got.Status != "failing" || got.Error != "" {
+		t.Errorf("failing check: got: %+v", got)
+	}
+
+	s.ReadyChecks["migrations"] = func(ctx context.Context) error { return nil }
+	workers[0].Healthy, workers[0].Error = false, "lease lost"
+	status, readiness = getReadiness(t, s.Url())
+	if status != http.StatusServiceUnavailable || readiness.Status != "not_ready" {
+		t.Errorf("unhealthy worker: got: %d %s", status, readiness.Status)
+	}
+	if len(readiness.Workers) != 1 || readiness.Workers[0].Healthy || readiness.Workers[0].Error != "" {
+		t.Errorf("unhealthy worker: got: %+v", readiness.Workers)
+	}
+}
+
+func TestReadyz_AdminDetails(t *testing.T) {
+	s := frshttp.NewHttpServer()
+	s.Addr, s.AdminAddr = "localhost:0", "localhost:0"
+	s.ReadyChecks = map[string]func(context.Context) error{
+		"database": func(ctx context.Context) error { return errors.New("dial tcp 10.0.0.5:5432: connection refused") },
+	}
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	if _, readiness := getReadiness(t, s.Url()); readiness.Checks["database"].Error != "" {
+		t.Errorf("public: got: %+v", readiness.Checks["database"])
+	}
+
+	status, readiness := getReadiness(t, s.AdminUrl())
+	if got := readiness.Checks["database"]; status != http.StatusServiceUnavailable || got.Error != "dial tcp 10.0.0.5:5432: connection refused" {
+		t.Errorf("admin: got: %d %+v", status, got)
+	}
+}
+
+func TestReadyz_ShuttingDown(t *testing.T) {
+	s, _ := newFundRaiserServer(t)
+	s.ShutdownDelay = 500 * time.Millisecond
+
+	closed := make(chan error)
+	go func() { closed <- s.Close() }()
+
+	// readiness fails while the server still answers, before draining
+	deadline := time.Now().Add(s.ShutdownDelay)
+	for {
+		status, readiness := getReadiness(t, s.Url())
+		if status == http.StatusServiceUnavailable && readiness.Status == "shutting_down" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("still ready during shutdown: %d %s", status, readiness.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,65 @@
+package http_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	frshttp "github.com/TezzBhandari/frs/http"
+)
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	s, _ := newFundRaiserServer(t)
+
+	get(t, s.Url()+"/api/v1/fund-raiser?limit=5")
+	get(t, s.Url()+"/api/v1/fund-raiser/abc")
+
+	status, body := get(t, s.Url()+"/metrics")
+	if status != http.StatusOK {
+		t.Fatalf("got: %d, want: %d", status, http.StatusOK)
+	}
+	for _, want := range []string{
+		`frs_http_requests_total{method="GET",route="/api/v1/fund-raiser",status="200"}`,
+		// labelled by the template, not the id
+		`frs_http_requests_total{method="GET",route="/api/v1/fund-raiser/{id}",status="400"}`,
+		`frs_http_request_duration_seconds_bucket{method="GET",route="/api/v1/fund-raiser"`,
+		`frs_http_requests_in_flight 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
+
+func TestMetrics_AdminListener(t *testing.T) {
+	s := frshttp.NewHttpServer()
+	s.Addr = "localhost:0"
+	s.AdminAddr = "localhost:0"
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	if status, _ := get(t, s.Url()+"/metrics"); status != http.StatusNotFound {
+		t.Errorf("public listener: got: %d, want: %d", status, http.StatusNotFound)
+	}
+	if status, _ := get(t, s.AdminUrl()+"/metrics"); status != http.StatusOK {
+		t.Errorf("admin listener: got: %d, want: %d", status, http.StatusOK)
+	}
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/oidc"
+	"github.com/TezzBhandari/frs/utils"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// holds state, nonce and PKCE verifier between the redirect and the callback
+const oidcCookieName = "frs_oidc"
+
+const oidcCookieMaxAge = 10 * time.Minute
+
+func (s *Server) registerOIDCRoutes(r *mux.Router) {
+	r.HandleFunc("/auth/oidc/{provider}/login", s.handleOIDCLogin).Methods(http.MethodGet)
+	r.HandleFunc("/auth/oidc/{provider}/callback", s.handleOIDCCallback).Methods(http.MethodGet)
+}
+
+func (s *Server) handleOIDCLogin(rw http.ResponseWriter, r *http.Request) {
+	provider, ok := s.OIDCProviders[mux.Vars(r)["provider"]]
+	if !ok {
+		Error(rw, r, frs.Errorf(frs.ENOTFOUND, utils.DoesNotExistMsg("identity provider")))
+		return
+	}
+
+	values := make([]string, 3)
+	for i := range values {
+		v, err := oidc.RandomString()
+		if err != nil {
+			Error(rw, r, err)
+			return
+		}
+		values[i] = v
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	http.SetCookie(rw, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    strings.Join(values, "."),
+		Path:     "/api/v1/auth/oidc/" + provider.Name,
+		MaxAge:   int(oidcCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(rw, r, provider.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+}
+
+func (s *Server) handleOIDCCallback(rw http.ResponseWriter, r *http.Request) {
+	provider, ok := s.OIDCProviders[mux.Vars(r)["provider"]]
+	if !ok {
+		Error(rw, r, frs.Errorf(frs.ENOTFOUND, utils.DoesNotExistMsg("identity provider")))
+		return
+	}
+
+	query := r.URL.Query()
+	if e := query.Get("error"); e != "" {
+		Error(rw, r, frs.Errorf(frs.EUNAUTHORIZED, "identity provider returned %s", e))
+		return
+	}
+
+	cookie, err := r.Cookie(oidcCookieName)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EBADREQUEST, "login session expired"))
+		return
+	}
+
+	// the cookie is single use
+	http.SetCookie(rw, &http.Cookie{
+		Name:   oidcCookieName,
+		Path:   cookie.Path,
+		MaxAge: -1,
+	})
+
+	values := strings.Split(cookie.Value, ".")
+	if len(values) != 3 || query.Get("state") != values[0] {
+		Error(rw, r, frs.Errorf(frs.EBADREQUEST, "invalid login state"))
+		return
+	}
+	nonce, verifier := values[1], values[2]
+
+	rawIDToken, err := provider.Exchange(r.Context(), query.Get("code"), verifier)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EUNAUTHORIZED, "cannot exchange authorization code: %s", err))
+		return
+	}
+
+	claims, err := provider.Verify(r.Context(), rawIDToken, nonce)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EUNAUTHORIZED, "%s", err))
+		return
+	}
+
+	auth, err := s.AuthService.LoginWithIdentity(r.Context(), &frs.Identity{
+		Provider:      provider.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	})
+	if err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(SuccessResponse{
+		Data: map[string]any{
+			"auth": auth,
+		},
+	}); err != nil {
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+	}
+}
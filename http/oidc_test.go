@@ -0,0 +1,330 @@
+package http_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	frshttp "github.com/TezzBhandari/frs/http"
+	"github.com/TezzBhandari/frs/oidc"
+)
+
+// provider is a minimal OpenID Connect provider standing in for google & co.
+type provider struct {
+	*httptest.Server
+	t   *testing.T
+	key *rsa.PrivateKey
+	// when set tokens are signed with it instead of the published key
+	forgedKey *rsa.PrivateKey
+
+	// claims put into every id token
+	subject       string
+	email         string
+	emailVerified bool
+
+	mu    sync.Mutex
+	codes map[string]url.Values
+}
+
+func newProvider(t *testing.T) *provider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &provider{
+		t:             t,
+		key:           key,
+		subject:       "user-1",
+		email:         "jane@example.com",
+		emailVerified: true,
+		codes:         make(map[string]url.Values),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.handleDiscovery)
+	mux.HandleFunc("/authorize", p.handleAuthorize)
+	mux.HandleFunc("/token", p.handleToken)
+	mux.HandleFunc("/jwks", p.handleJWKS)
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *provider) handleDiscovery(rw http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(rw).Encode(map[string]string{
+		"issuer":                 p.URL,
+		"authorization_endpoint": p.URL + "/authorize",
+		"token_endpoint":         p.URL + "/token",
+		"jwks_uri":               p.URL + "/jwks",
+	})
+}
+
+// handleAuthorize logs the user in straight away and redirects back.
+func (p *provider) handleAuthorize(rw http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(rw, "pkce required", http.StatusBadRequest)
+		return
+	}
+
+	code, _ := oidc.RandomString()
+	p.mu.Lock()
+	p.codes[code] = query
+	p.mu.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	redirect.RawQuery = url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+	http.Redirect(rw, r, redirect.String(), http.StatusFound)
+}
+
+func (p *provider) handleToken(rw http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, _ := r.BasicAuth()
+	if clientID != "frs" || clientSecret != "secret" {
+		rw.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(rw).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	authorize, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != authorize.Get("code_challenge") {
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	json.NewEncoder(rw).Encode(map[string]string{
+		"access_token": "ignored",
+		"id_token": p.sign(map[string]any{
+			"iss":            p.URL,
+			"sub":            p.subject,
+			"aud":            authorize.Get("client_id"),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"nonce":          authorize.Get("nonce"),
+			"email":          p.email,
+			"email_verified": p.emailVerified,
+		}),
+	})
+}
+
+func (p *provider) handleJWKS(rw http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(rw).Encode(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *provider) sign(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	key := p.key
+	if p.forgedKey != nil {
+		key = p.forgedKey
+	}
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+type authService struct {
+	frs.AuthService
+	identities []*frs.Identity
+	// users with a password by email, their identities aren't linked
+	passwords map[string]bool
+}
+
+func (s *authService) LoginWithIdentity(ctx context.Context, identity *frs.Identity) (*frs.Auth, error) {
+	s.identities = append(s.identities, identity)
+	if s.passwords[identity.Email] {
+		return nil, frs.Errorf(frs.ECONFLICT, "an account with this email already exists, log in with its password to link the identity")
+	}
+	return &frs.Auth{ID: 1, AccessToken: "token"}, nil
+}
+
+func newOIDCServer(t *testing.T, p *provider) (*frshttp.Server, *authService) {
+	s := frshttp.NewHttpServer()
+	s.Addr = "localhost:0"
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	config := oidc.Config{
+		Name:         "local",
+		Issuer:       p.URL,
+		ClientID:     "frs",
+		ClientSecret: "secret",
+		RedirectURL:  s.Url() + "/api/v1/auth/oidc/local/callback",
+	}
+	local, err := oidc.NewProvider(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := &authService{}
+	s.AuthService = auth
+	s.OIDCProviders = map[string]*oidc.Provider{"local": local}
+	return s, auth
+}
+
+// login walks through the redirects like a browser would and returns the
+// callback response. tamper may change the callback query.
+func login(t *testing.T, s *frshttp.Server, tamper func(url.Values)) *http.Response {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(s.Url() + "/api/v1/auth/oidc/local/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("login: got: %d, want: %d", resp.StatusCode, http.StatusFound)
+	}
+	cookies := resp.Cookies()
+
+	resp, err = client.Get(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := callback.Query()
+	if tamper != nil {
+		tamper(query)
+	}
+	callback.RawQuery = query.Encode()
+
+	req, _ := http.NewRequest(http.MethodGet, callback.String(), nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestOIDCLogin(t *testing.T) {
+	p := newProvider(t)
+	s, auth := newOIDCServer(t, p)
+
+	resp := login(t, s, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("callback: got: %d, want: %d", resp.StatusCode, http.StatusOK)
+	}
+
+	body := struct {
+		Data struct {
+			Auth frs.Auth `json:"auth"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data.Auth.AccessToken != "token" {
+		t.Errorf("access token: got: %q, want: %q", body.Data.Auth.AccessToken, "token")
+	}
+
+	if len(auth.identities) != 1 {
+		t.Fatalf("identities: got: %d, want: 1", len(auth.identities))
+	}
+	want := frs.Identity{Provider: "local", Subject: "user-1", Email: "jane@example.com", EmailVerified: true}
+	if got := *auth.identities[0]; got != want {
+		t.Errorf("identity: got: %+v, want: %+v", got, want)
+	}
+}
+
+func TestOIDCLogin_StateMismatch(t *testing.T) {
+	p := newProvider(t)
+	s, auth := newOIDCServer(t, p)
+
+	resp := login(t, s, func(query url.Values) { query.Set("state", "forged") })
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("callback: got: %d, want: %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if len(auth.identities) != 0 {
+		t.Errorf("identities: got: %d, want: 0", len(auth.identities))
+	}
+}
+
+func TestOIDCLogin_InvalidSignature(t *testing.T) {
+	p := newProvider(t)
+	s, auth := newOIDCServer(t, p)
+
+	forgedKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.forgedKey = forgedKey
+
+	resp := login(t, s, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("callback: got: %d, want: %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if len(auth.identities) != 0 {
+		t.Errorf("identities: got: %d, want: 0", len(auth.identities))
+	}
+}
+
+// someone registered the provider account's email with a password first
+func TestOIDCLogin_PasswordAccountExists(t *testing.T) {
+	p := newProvider(t)
+	s, auth := newOIDCServer(t, p)
+	auth.passwords = map[string]bool{p.email: true}
+
+	resp := login(t, s, nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("callback: got: %d, want: %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	body := struct {
+		Data struct {
+			Auth *frs.Auth `json:"auth"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data.Auth != nil {
+		t.Errorf("auth: got: %+v, want: none", body.Data.Auth)
+	}
+}
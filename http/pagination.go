@@ -0,0 +1,88 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/TezzBhandari/frs"
+)
+
+// Meta describes where a page sits in the full list of results.
+type Meta struct {
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	Next   string `json:"next,omitempty"`
+	Prev   string `json:"prev,omitempty"`
+	// pass as cursor to fetch the rows after this page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// page is what a list handler knows about the results it is returning.
+type page struct {
+	total  int
+	limit  int
+	offset int
+	// cursor the page was requested with, if any
+	cursor string
+	// position of the last row, nil when the page is empty
+	last *frs.Cursor
+	size int
+}
+
+// newMeta builds the pagination block for a list response. Links keep every
+// other query parameter of the request.
+func newMeta(r *http.Request, p page) *Meta {
+	limit := p.limit
+	if limit <= 0 {
+		limit = frs.DefaultLimit
+	}
+
+	meta := &Meta{
+		Total:  p.total,
+		Limit:  limit,
+		Offset: p.offset,
+	}
+
+	// a full page means there may be more rows after it
+	if p.last != nil && p.size == limit {
+		meta.NextCursor = p.last.Encode()
+	}
+
+	// keyset pages can only move forward
+	if p.cursor != "" {
+		if meta.NextCursor != "" {
+			meta.Next = pageURL(r, map[string]string{
+				"limit":  strconv.Itoa(limit),
+				"cursor": meta.NextCursor,
+			})
+		}
+		return meta
+	}
+
+	if p.offset+limit < p.total {
+		meta.Next = pageURL(r, map[string]string{
+			"limit":  strconv.Itoa(limit),
+			"offset": strconv.Itoa(p.offset + limit),
+		})
+	}
+
+	if p.offset > 0 {
+		meta.Prev = pageURL(r, map[string]string{
+			"limit":  strconv.Itoa(limit),
+			"offset": strconv.Itoa(max(p.offset-limit, 0)),
+		})
+	}
+	return meta
+}
+
+func pageURL(r *http.Request, params map[string]string) string {
+	query := r.URL.Query()
+	for k, v := range params {
+		query.Set(k, v)
+	}
+
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
@@ -0,0 +1,36 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	frshttp "github.com/TezzBhandari/frs/http"
+)
+
+func TestFindFundRaiser_Meta(t *testing.T) {
+	s, service := newFundRaiserServer(t)
+	service.total = 25
+
+	resp, err := http.Get(s.Url() + "/api/v1/fund-raiser?title=water&limit=10&offset=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body := frshttp.SuccessResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	want := frshttp.Meta{
+		Total:  25,
+		Limit:  10,
+		Offset: 10,
+		Next:   "/api/v1/fund-raiser?limit=10&offset=20&title=water",
+		Prev:   "/api/v1/fund-raiser?limit=10&offset=0&title=water",
+	}
+	if body.Meta == nil || *body.Meta != want {
+		t.Errorf("got: %+v, want: %+v", body.Meta, want)
+	}
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/utils"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// applyMergePatch applies the RFC 7386 merge patch in the request body to
+// current and decodes the result into merged. Fields set to null in the patch
+// are cleared, absent fields are kept.
+func applyMergePatch(r *http.Request, current any, merged any) error {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != mergePatchContentType {
+		return frs.Errorf(frs.EBADREQUEST, "content type must be %s", mergePatchContentType)
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	var patch any
+	if err := json.Unmarshal(buf, &patch); err != nil {
+		return frs.Errorf(frs.EBADREQUEST, utils.InvalidJsonMsg())
+	}
+
+	// round trip through json so the patch sees the wire representation
+	buf, err = json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	var target any
+	if err := json.Unmarshal(buf, &target); err != nil {
+		return err
+	}
+
+	buf, err = json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(buf, merged); err != nil {
+		return frs.Errorf(frs.EBADREQUEST, "patch produces an invalid document: %s", err)
+	}
+	return nil
+}
+
+// mergePatch implements the MergePatch function of RFC 7386.
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,87 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+)
+
+func (s *fundRaiserService) FindFundRaiserById(ctx context.Context, id int64) (*frs.FundRaiser, error) {
+	if s.current == nil || s.current.ID != id {
+		return nil, frs.Errorf(frs.ENOTFOUND, "fundraiser does not exist")
+	}
+	fundRaiser := *s.current
+	return &fundRaiser, nil
+}
+
+func (s *fundRaiserService) ReplaceFundRaiser(ctx context.Context, id int64, fundRaiser *frs.FundRaiser) (*frs.FundRaiser, error) {
+	if err := fundRaiser.Validate(); err != nil {
+		return nil, err
+	}
+	if fundRaiser.Version != 0 && fundRaiser.Version != s.current.Version {
+		return nil, frs.Errorf(frs.EPRECONDITION, "stale")
+	}
+	s.replaced = fundRaiser
+	fundRaiser.Version = s.current.Version + 1
+	return fundRaiser, nil
+}
+
+func patch(t *testing.T, url, contentType, body string) *http.Response {
+	req, _ := http.NewRequest(http.MethodPatch, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("If-Match", "*")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestPatchFundRaiser(t *testing.T) {
+	s, service := newFundRaiserServer(t)
+	endsAt := time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC)
+	service.current = &frs.FundRaiser{ID: 7, Title: "Clean water", Story: "Wells for the village", CoverImg: "well.png", TargetAmount: 500, EndsAt: &endsAt}
+
+	resp := patch(t, s.Url()+"/api/v1/fund-raiser/7", "application/merge-patch+json", `{"title": "Clean water now", "ends_at": null}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got: %d, want: %d", resp.StatusCode, http.StatusOK)
+	}
+
+	got := service.replaced
+	if got.Title != "Clean water now" {
+		t.Errorf("title: got: %q, want: %q", got.Title, "Clean water now")
+	}
+	if got.EndsAt != nil {
+		t.Errorf("ends_at: got: %v, want: nil", got.EndsAt)
+	}
+	if got.Story != "Wells for the village" || got.CoverImg != "well.png" || got.TargetAmount != 500 {
+		t.Errorf("untouched fields changed: %+v", got)
+	}
+}
+
+func TestPatchFundRaiser_InvalidResult(t *testing.T) {
+	s, service := newFundRaiserServer(t)
+	service.current = &frs.FundRaiser{ID: 7, Title: "Clean water", Story: "Wells", CoverImg: "well.png", TargetAmount: 500}
+
+	// clearing a required field fails validation of the merged document
+	if resp := patch(t, s.Url()+"/api/v1/fund-raiser/7", "application/merge-patch+json", `{"title": null}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("cleared title: got: %d, want: %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	if resp := patch(t, s.Url()+"/api/v1/fund-raiser/7", "application/json", `{"title": "x"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("content type: got: %d, want: %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	if resp := patch(t, s.Url()+"/api/v1/fund-raiser/8", "application/merge-patch+json", `{}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing: got: %d, want: %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	if service.replaced != nil {
+		t.Errorf("replaced: got: %+v, want: nil", service.replaced)
+	}
+}
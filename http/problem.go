@@ -0,0 +1,63 @@
+package http
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TezzBhandari/frs"
+)
+
+const problemContentType = "application/problem+json"
+
+// problem types are URNs so they stay stable without a hosted error catalogue
+const problemTypePrefix = "urn:frs:problem:"
+
+// Problem is an RFC 7807 error body. Code carries the frs error code so
+// clients can branch on it instead of parsing Detail.
+type Problem struct {
+	Type      string           `json:"type"`
+	Title     string           `json:"title"`
+	Status    int              `json:"status"`
+	Detail    string           `json:"detail,omitempty"`
+	Instance  string           `json:"instance,omitempty"`
+	Code      string           `json:"code"`
+	RequestID string           `json:"request_id,omitempty"`
+	Fields    []frs.FieldError `json:"fields,omitempty"`
+}
+
+func newProblem(r *http.Request, err error) *Problem {
+	code := frs.ErrorCode(err)
+	status := ErrorStatusCode(code)
+	return &Problem{
+		Type:      problemTypePrefix + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    frs.ErrorMessage(err),
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: requestIDOf(r),
+		Fields:    frs.ErrorFields(err),
+	}
+}
+
+// acceptsProblem reports whether the client asked for problem+json. Anything
+// else, including no Accept header, gets the legacy ErrorResponse.
+func acceptsProblem(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || mediaType != problemContentType {
+				continue
+			}
+			if q, ok := params["q"]; ok {
+				if v, err := strconv.ParseFloat(q, 64); err != nil || v == 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// QueryParamError reports a query parameter that could not be parsed. It
+// unwraps to an EINVALID frs.Error so it is rendered as a 400.
+type QueryParamError struct {
+	Param  string
+	Value  string
+	Reason string
+}
+
+func (e *QueryParamError) Error() string {
+	return fmt.Sprintf("invalid query parameter %s=%q: %s", e.Param, e.Value, e.Reason)
+}
+
+func (e *QueryParamError) Unwrap() error {
+	return frs.Errorf(frs.EINVALID, "invalid query parameter %s: %s", e.Param, e.Reason)
+}
+
+// queryParams reads typed values out of a query string. The first parse
+// error is kept and every later read is a no-op.
+type queryParams struct {
+	values url.Values
+	err    error
+}
+
+func (q *queryParams) fail(param, value, reason string) {
+	if q.err == nil {
+		q.err = &QueryParamError{Param: param, Value: value, Reason: reason}
+	}
+}
+
+func (q *queryParams) String(name string) *string {
+	if q.err != nil || !q.values.Has(name) {
+		return nil
+	}
+	v := q.values.Get(name)
+	return &v
+}
+
+func (q *queryParams) Int64(name string) *int64 {
+	if q.err != nil || !q.values.Has(name) {
+		return nil
+	}
+	v := q.values.Get(name)
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		q.fail(name, v, "must be an integer")
+		return nil
+	}
+	return &i
+}
+
+// Int64s accepts a comma separated list, repeated parameters are merged.
+func (q *queryParams) Int64s(name string) []int64 {
+	if q.err != nil || !q.values.Has(name) {
+		return nil
+	}
+
+	ids := make([]int64, 0)
+	for _, v := range q.values[name] {
+		for _, part := range strings.Split(v, ",") {
+			i, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				q.fail(name, v, "must be a comma separated list of integers")
+				return nil
+			}
+			ids = append(ids, i)
+		}
+	}
+	return ids
+}
+
+func (q *queryParams) Float64(name string) *float64 {
+	if q.err != nil || !q.values.Has(name) {
+		return nil
+	}
+	v := q.values.Get(name)
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		q.fail(name, v, "must be a number")
+		return nil
+	}
+	return &f
+}
+
+// Int returns the non negative integer or zero when the parameter is missing.
+func (q *queryParams) Int(name string) int {
+	if q.err != nil || !q.values.Has(name) {
+		return 0
+	}
+	v := q.values.Get(name)
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		q.fail(name, v, "must be an integer")
+		return 0
+	}
+	if i < 0 {
+		q.fail(name, v, "must not be negative")
+		return 0
+	}
+	return i
+}
+
+// Time accepts RFC 3339 timestamps or plain dates.
+func (q *queryParams) Time(name string) *time.Time {
+	if q.err != nil || !q.values.Has(name) {
+		return nil
+	}
+	v := q.values.Get(name)
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return &t
+		}
+	}
+	q.fail(name, v, "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+	return nil
+}
+
+func parseFilterFundRaiser(rw http.ResponseWriter, r *http.Request) (*frs.FilterFundRaiser, error) {
+	filter := &frs.FilterFundRaiser{}
+	if r.URL.RawQuery == "" {
+		if err := readDeprecatedFilterBody(rw, r, filter); err != nil {
+			return nil, err
+		}
+		return filter, filter.Validate()
+	}
+
+	q := &queryParams{values: r.URL.Query()}
+	filter.ID = q.Int64("id")
+	filter.IDs = q.Int64s("ids")
+	filter.Title = q.String("title")
+	// an empty search is the same as no search
+	if query := q.String("q"); query != nil && strings.TrimSpace(*query) != "" {
+		filter.Query = query
+	}
+	filter.CreatedAt = q.Time("created_at")
+	filter.CreatedAfter = q.Time("created_after")
+	filter.CreatedBefore = q.Time("created_before")
+	filter.TargetMin = q.Float64("target_min")
+	filter.TargetMax = q.Float64("target_max")
+	if sort := q.String("sort"); sort != nil {
+		filter.Sort = *sort
+	}
+	if order := q.String("order"); order != nil {
+		filter.Order = *order
+	}
+	filter.Limit = q.Int("limit")
+	filter.Offset = q.Int("offset")
+	if cursor := q.String("cursor"); cursor != nil {
+		filter.Cursor = *cursor
+	}
+	if q.err != nil {
+		return nil, q.err
+	}
+	return filter, filter.Validate()
+}
+
+func parseFilterUser(rw http.ResponseWriter, r *http.Request) (*frs.FilterUser, error) {
+	filter := &frs.FilterUser{}
+	if r.URL.RawQuery == "" {
+		if err := readDeprecatedFilterBody(rw, r, filter); err != nil {
+			return nil, err
+		}
+		return filter, filter.Validate()
+	}
+
+	q := &queryParams{values: r.URL.Query()}
+	filter.ID = q.Int64("id")
+	filter.Username = q.String("username")
+	filter.Email = q.String("email")
+	filter.Limit = q.Int("limit")
+	filter.Offset = q.Int("offset")
+	if cursor := q.String("cursor"); cursor != nil {
+		filter.Cursor = *cursor
+	}
+	if q.err != nil {
+		return nil, q.err
+	}
+	return filter, filter.Validate()
+}
+
+// readDeprecatedFilterBody decodes filters sent as a GET body, which older
+// clients still do. Browsers and proxies drop such bodies so clients are
+// told to move to query parameters with a Deprecation header.
+func readDeprecatedFilterBody(rw http.ResponseWriter, r *http.Request, filter any) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace(buf)) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(buf, filter); err != nil {
+		return frs.Errorf(frs.EBADREQUEST, utils.InvalidJsonMsg())
+	}
+
+	rw.Header().Set("Deprecation", "true")
+	log.Ctx(r.Context()).Warn().Str("path", r.URL.Path).Msg("filter read from deprecated GET request body")
+	return nil
+}
@@ -0,0 +1,196 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	frshttp "github.com/TezzBhandari/frs/http"
+)
+
+type fundRaiserService struct {
+	frs.FundRaiserService
+	filter *frs.FilterFundRaiser
+	total  int
+
+	// returned by FindFundRaiserById, ReplaceFundRaiser stores its argument
+	// in replaced
+	current  *frs.FundRaiser
+	replaced *frs.FundRaiser
+}
+
+func (s *fundRaiserService) FindFundRaiser(ctx context.Context, filter *frs.FilterFundRaiser) ([]*frs.FundRaiser, int, error) {
+	s.filter = filter
+	return []*frs.FundRaiser{}, s.total, nil
+}
+
+func newFundRaiserServer(t *testing.T) (*frshttp.Server, *fundRaiserService) {
+	s := frshttp.NewHttpServer()
+	s.Addr = "localhost:0"
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	service := &fundRaiserService{}
+	s.FundRaiserService = service
+	return s, service
+}
+
+func TestFindFundRaiser_QueryParams(t *testing.T) {
+	s, service := newFundRaiserServer(t)
+
+	resp, err := http.Get(s.Url() + "/api/v1/fund-raiser?id=42&title=clean+water&created_at=2024-05-01&limit=5&offset=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got: %d, want: %d", resp.StatusCode, http.StatusOK)
+	}
+
+	filter := service.filter
+	if filter.ID == nil || *filter.ID != 42 {
+		t.Errorf("id: got: %v, want: 42", filter.ID)
+	}
+	if filter.Title == nil || *filter.Title != "clean water" {
+		t.Errorf("title: got: %v, want: %q", filter.Title, "clean water")
+	}
+	if want := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC); filter.CreatedAt == nil || !filter.CreatedAt.Equal(want) {
+		t.Errorf("created_at: got: %v, want: %s", filter.CreatedAt, want)
+	}
+	if filter.Limit != 5 || filter.Offset != 10 {
+		t.Errorf("limit, offset: got: %d, %d, want: 5, 10", filter.Limit, filter.Offset)
+	}
+}
+
+func TestFindFundRaiser_RangeAndSortParams(t *testing.T) {
+	s, service := newFundRaiserServer(t)
+
+	resp, err := http.Get(s.Url() + "/api/v1/fund-raiser?ids=1,2&ids=3&created_after=2024-01-01&created_before=2024-02-01T00:00:00Z&target_min=100&target_max=2500.50&sort=ending_soon&order=desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got: %d, want: %d", resp.StatusCode, http.StatusOK)
+	}
+
+	filter := service.filter
+	if want := []int64{1, 2, 3}; !reflect.DeepEqual(filter.IDs, want) {
+		t.Errorf("ids: got: %v, want: %v", filter.IDs, want)
+	}
+	if filter.CreatedAfter == nil || filter.CreatedBefore == nil {
+		t.Errorf("created range: got: %v, %v", filter.CreatedAfter, filter.CreatedBefore)
+	}
+	if filter.TargetMin == nil || *filter.TargetMin != 100 || filter.TargetMax == nil || *filter.TargetMax != 2500.50 {
+		t.Errorf("target range: got: %v, %v", filter.TargetMin, filter.TargetMax)
+	}
+	if filter.Sort != frs.SortEndingSoon || filter.Order != frs.SortDesc {
+		t.Errorf("sort: got: %q %q", filter.Sort, filter.Order)
+	}
+}
+
+func TestFindFundRaiser_InvalidQueryParam(t *testing.T) {
+	s, service := newFundRaiserServer(t)
+
+	for _, query := range []string{
+		"id=abc",
+		"ids=1,x",
+		"limit=-1",
+		"created_at=yesterday",
+		"target_min=lots",
+		"sort=title",
+		// nothing records donations yet, see FundRaiser.AmountRaised
+		"sort=amount_raised",
+		"order=sideways",
+		"target_min=10&target_max=5",
+		"sort=relevance",
+	} {
+		resp, err := http.Get(s.Url() + "/api/v1/fund-raiser?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: got: %d, want: %d", query, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+
+	if service.filter != nil {
+		t.Errorf("service called with invalid query")
+	}
+}
+
+func TestFindFundRaiser_Search(t *testing.T) {
+	s, service := newFundRaiserServer(t)
+	service.total = 1
+
+	resp, err := http.Get(s.Url() + "/api/v1/fund-raiser?q=clean+water+-bottled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got: %d, want: %d", resp.StatusCode, http.StatusOK)
+	}
+
+	filter := service.filter
+	if filter.Query == nil || *filter.Query != "clean water -bottled" {
+		t.Errorf("query: got: %v, want: %q", filter.Query, "clean water -bottled")
+	}
+	if got := filter.SortKey(); got != frs.SortRelevance {
+		t.Errorf("sort: got: %q, want: %q", got, frs.SortRelevance)
+	}
+
+	// an empty search is ignored
+	if resp, err = http.Get(s.Url() + "/api/v1/fund-raiser?q=+"); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if service.filter.Query != nil {
+		t.Errorf("query: got: %q, want: nil", *service.filter.Query)
+	}
+}
+
+func TestFindFundRaiser_DeprecatedBody(t *testing.T) {
+	s, service := newFundRaiserServer(t)
+
+	req, _ := http.NewRequest(http.MethodGet, s.Url()+"/api/v1/fund-raiser", strings.NewReader(`{"limit": 3}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got := resp.Header.Get("Deprecation"); got != "true" {
+		t.Errorf("Deprecation header: got: %q, want: %q", got, "true")
+	}
+	if service.filter.Limit != 3 {
+		t.Errorf("limit: got: %d, want: 3", service.filter.Limit)
+	}
+}
+
+func TestFindFundRaiser_DeprecatedBodyInvalid(t *testing.T) {
+	s, service := newFundRaiserServer(t)
+
+	for _, body := range []string{`{"sort": "title"}`, `{"limit": -1}`, `{"target_min": 10, "target_max": 5}`} {
+		req, _ := http.NewRequest(http.MethodGet, s.Url()+"/api/v1/fund-raiser", strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: got: %d, want: %d", body, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+
+	if service.filter != nil {
+		t.Errorf("service called with invalid body")
+	}
+}
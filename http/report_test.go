@@ -0,0 +1,90 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/TezzBhandari/frs"
+	frshttp "github.com/TezzBhandari/frs/http"
+)
+
+type panicReporter struct {
+	mu      sync.Mutex
+	reports []*frs.Report
+}
+
+func (r *panicReporter) Report(ctx context.Context, report *frs.Report) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+func TestReportPanic(t *testing.T) {
+	reporter := &panicReporter{}
+	frs.DefaultPanicReporter = reporter
+	t.Cleanup(func() { frs.DefaultPanicReporter = nil })
+
+	// the fake leaves CreateFundRaiser to its nil embedded interface
+	s, _ := newFundRaiserServer(t)
+	req, err := http.NewRequest(http.MethodPost, s.Url()+"/api/v1/fund-raiser?draft=1", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("User-Agent", "test-agent")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status: got: %d, want: %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	if len(reporter.reports) != 1 {
+		t.Fatalf("got: %d reports, want: 1", len(reporter.reports))
+	}
+	report := reporter.reports[0]
+	if report.Level != "fatal" || report.RequestID != "req-1" {
+		t.Errorf("got: %s %q", report.Level, report.RequestID)
+	}
+	if req := report.Request; req == nil || req.Method != http.MethodPost || req.URL != "/api/v1/fund-raiser?draft=1" || req.UserAgent != "test-agent" {
+		t.Errorf("request: got: %+v", report.Request)
+	}
+	// the fake's generated CreateFundRaiser wrapper comes first
+	if got := report.Stack[1].Function; !strings.HasSuffix(got, ".handleCreateFundRaiser") {
+		t.Errorf("second frame: got: %s", got)
+	}
+}
+
+func TestError_Report(t *testing.T) {
+	reporter := &panicReporter{}
+	frs.DefaultPanicReporter = reporter
+	t.Cleanup(func() { frs.DefaultPanicReporter = nil })
+
+	for _, err := range []error{
+		errors.New("connection refused"),
+		frs.Errorf(frs.ENOTFOUND, "user does not exist"),
+		fmt.Errorf("find users: %w", context.Canceled),
+		context.DeadlineExceeded,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		frshttp.Error(httptest.NewRecorder(), r, err)
+	}
+
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	if len(reporter.reports) != 1 || reporter.reports[0].Message != "connection refused" {
+		t.Errorf("got: %d reports, want only the unexpected error", len(reporter.reports))
+	}
+}
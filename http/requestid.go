@@ -0,0 +1,61 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/rs/zerolog/log"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// incoming ids are only kept when they are short and safe to log, anything
+// else is replaced
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestID accepts the caller's X-Request-ID or generates one, echoes it in
+// the response and attaches it with a logger carrying it to the context.
+// Handlers and services log through log.Ctx(ctx) so their lines share the id.
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !requestIDRegex.MatchString(id) {
+			id = newRequestID()
+		}
+		rw.Header().Set(requestIDHeader, id)
+
+		logger := log.With().Str("request_id", id).Logger()
+		ctx := logger.WithContext(frs.NewContextWithRequestID(r.Context(), id))
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// logRequest writes the access log line once the response is sent.
+func logRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		t := time.Now()
+		srw := &statusResponseWriter{ResponseWriter: rw}
+		next.ServeHTTP(srw, r)
+
+		log.Ctx(r.Context()).Info().
+			Str("method", r.Method).
+			Str("path", r.RequestURI).
+			Int("status", srw.Status()).
+			Int("size", srw.size).
+			Float64("request time", time.Since(t).Seconds()).
+			Str("remote address", r.RemoteAddr).
+			Msg("")
+	})
+}
@@ -0,0 +1,103 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	frshttp "github.com/TezzBhandari/frs/http"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestRequestID(t *testing.T) {
+	s, _ := newFundRaiserServer(t)
+
+	for _, tt := range []struct {
+		header string
+		echoed bool
+	}{
+		{"", false},
+		{"req-42.a:b_c", true},
+		{"has spaces", false},
+		{strings.Repeat("a", 129), false},
+	} {
+		req, err := http.NewRequest(http.MethodGet, s.Url()+"/api/v1/fund-raiser/abc", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.header != "" {
+			req.Header.Set("X-Request-ID", tt.header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var body frshttp.ErrorResponse
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		id := resp.Header.Get("X-Request-ID")
+		if tt.echoed && id != tt.header {
+			t.Errorf("%q: got: %q, want it echoed", tt.header, id)
+		} else if !tt.echoed && !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(id) {
+			t.Errorf("%q: got: %q, want a generated id", tt.header, id)
+		}
+		if body.RequestID != id {
+			t.Errorf("%q: error body: got: %q, want: %q", tt.header, body.RequestID, id)
+		}
+	}
+}
+
+func TestRequestID_AccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = logger })
+
+	s, _ := newFundRaiserServer(t)
+	req, err := http.NewRequest(http.MethodGet, s.Url()+"/no/such/path", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Request-ID", "req-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	s.Close()
+
+	// the error and the access log line carry the same id
+	var lines []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var entry map[string]any
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		if entry["request_id"] == "req-1" {
+			lines = append(lines, entry)
+		}
+	}
+	if len(lines) != 2 {
+		t.Fatalf("got: %d lines with the request id, want: 2\n%s", len(lines), buf.String())
+	}
+
+	access := lines[1]
+	if access["status"] != float64(http.StatusNotFound) {
+		t.Errorf("status: got: %v", access["status"])
+	}
+	if size, _ := access["size"].(float64); size == 0 {
+		t.Errorf("size: got: %v", access["size"])
+	}
+	if access["path"] != "/no/such/path" {
+		t.Errorf("path: got: %v", access["path"])
+	}
+}
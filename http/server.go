@@ -3,16 +3,22 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/oidc"
 	"github.com/TezzBhandari/frs/utils"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Server struct {
@@ -20,31 +26,76 @@ type Server struct {
 	router *mux.Router
 	Addr   string
 
+	// applied when the server is opened
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+
 	ln net.Listener
 
+	// optional address of the admin listener serving /metrics, the metrics
+	// are served on Addr when empty. It also serves /readyz with the errors
+	// the public one leaves out.
+	AdminAddr   string
+	adminServer *http.Server
+	adminLn     net.Listener
+
+	AuthService       frs.AuthService
+	APIKeyService     frs.APIKeyService
+	SessionService    frs.SessionService
 	UserService       frs.UserService
 	FundRaiserService frs.FundRaiserService
+
+	// optional, failed logins are not throttled when nil
+	LoginThrottle *frs.LoginThrottle
+
+	// identity providers keyed by name
+	OIDCProviders map[string]*oidc.Provider
+
+	// creates the request spans, the global provider by default
+	TracerProvider trace.TracerProvider
+
+	// dependencies checked by /readyz, keyed by name
+	ReadyChecks map[string]func(ctx context.Context) error
+	// optional, background goroutines listed on /readyz
+	WorkerStatus func() []frs.WorkerStatus
+	// how long /readyz reports shutting down before Close drains
+	// connections, so load balancers stop routing new requests here first
+	ShutdownDelay time.Duration
+	shuttingDown  atomic.Bool
 }
 
 func NewHttpServer() *Server {
 	s := &Server{
-		server: &http.Server{
-			IdleTimeout:  2 * time.Second,
-			ReadTimeout:  1 * time.Second,
-			WriteTimeout: 1 * time.Second,
-		},
-		router: mux.NewRouter(),
+		server:          &http.Server{},
+		router:          mux.NewRouter(),
+		ReadTimeout:     1 * time.Second,
+		WriteTimeout:    1 * time.Second,
+		IdleTimeout:     2 * time.Second,
+		ShutdownTimeout: 2 * time.Second,
+		TracerProvider:  otel.GetTracerProvider(),
 	}
 
+	s.router.Use(s.trace)
 	s.router.Use(reportPanic)
 	s.router.Use(trackMetrics)
 
-	s.server.Handler = s.router
+	// outside the router so unmatched paths are logged and get an id too
+	s.server.Handler = requestID(logRequest(s.router))
 
 	s.router.NotFoundHandler = s.handleNotFound()
+	s.registerHealthRoutes(s.router)
 	router := s.router.PathPrefix("/api/v1").Subrouter()
+	router.Use(s.authenticate)
+	// again after authenticate so reports carry the user id
+	router.Use(reportPanic)
 
+	s.registerAuthRoutes(router)
+	s.registerOIDCRoutes(router)
 	s.registerUserRoutes(router)
+	s.registerAPIKeyRoutes(router)
+	s.registerSessionRoutes(router)
 	s.registerFundRaiserRoutes(router)
 
 	return s
@@ -56,6 +107,15 @@ func (s *Server) Open() error {
 		return fmt.Errorf("addr required")
 	}
 	s.server.Addr = s.Addr
+	s.server.ReadTimeout = s.ReadTimeout
+	s.server.WriteTimeout = s.WriteTimeout
+	s.server.IdleTimeout = s.IdleTimeout
+
+	if s.AdminAddr == "" {
+		s.router.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
+	} else if err := s.openAdmin(); err != nil {
+		return err
+	}
 
 	if s.ln, err = net.Listen("tcp", s.Addr); err != nil {
 		return err
@@ -64,28 +124,55 @@ func (s *Server) Open() error {
 	// Begin serving requests on the listener. We use Serve() instead of
 	// ListenAndServe() because it allows us to check for listen errors (such
 	// as trying to use an already open port) synchronously.
-	go s.server.Serve(s.ln)
+	frs.Go(context.Background(), "http server", func() { s.server.Serve(s.ln) })
+
+	return nil
+}
+
+// openAdmin serves /metrics and the detailed /readyz on a listener of its
+// own, so they can be kept off the public network.
+func (s *Server) openAdmin() error {
+	var err error
+	if s.adminLn, err = net.Listen("tcp", s.AdminAddr); err != nil {
+		return err
+	}
+
+	router := mux.NewRouter()
+	router.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", s.handleAdminReadyz).Methods(http.MethodGet)
+	s.adminServer = &http.Server{
+		Handler:      router,
+		ReadTimeout:  s.ReadTimeout,
+		WriteTimeout: s.WriteTimeout,
+		IdleTimeout:  s.IdleTimeout,
+	}
+	frs.Go(context.Background(), "admin http server", func() { s.adminServer.Serve(s.adminLn) })
 
 	return nil
 }
 
 func (s *Server) Close() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	s.shuttingDown.Store(true)
+	if s.ln != nil {
+		time.Sleep(s.ShutdownDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
 	defer func() {
 		defer cancel()
 		defer log.Info().Msg("server gracefully shutdown")
 	}()
+	if s.adminServer != nil {
+		if err := s.adminServer.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
 	return s.server.Shutdown(ctx)
 }
 
 func (s *Server) handleNotFound() http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(http.StatusNotFound)
-		err := json.NewEncoder(rw).Encode(ErrorResponse{Error: "path not found"})
-		if err != nil {
-			log.Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
-		}
+		Error(rw, r, frs.Errorf(frs.ENOTFOUND, "path not found"))
 	})
 }
 
@@ -100,56 +187,114 @@ func (s *Server) Port() int {
 	return s.ln.Addr().(*net.TCPAddr).Port
 }
 
+// AdminUrl returns the url of the admin listener, empty when there is none.
+func (s *Server) AdminUrl() string {
+	if s.adminLn == nil {
+		return ""
+	}
+	return fmt.Sprintf("http://localhost:%d", s.adminLn.Addr().(*net.TCPAddr).Port)
+}
+
 // middleware catches panics and reports to external service
 func reportPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Error().Any("err", err).Msg("panic error")
+				report := frs.NewPanicReport(r.Context(), err)
+				report.Request = newReportRequest(r)
+				log.Ctx(r.Context()).Error().Any("err", err).Str("stack", report.StackTrace()).Msg("panic error")
+				if err := frs.SendReport(r.Context(), report); err != nil {
+					log.Ctx(r.Context()).Error().Err(err).Msg("cannot report panic")
+				}
 				Error(rw, r, frs.Errorf(frs.EINTERNAL, "internal error"))
-				frs.ReportPanic(err)
 			}
 		}()
 		next.ServeHTTP(rw, r)
 	})
 }
 
-func trackMetrics(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		t := time.Now()
-		next.ServeHTTP(rw, r)
-		timeTaken := float64(time.Since(t).Seconds())
-		log.Info().Float64("request time", timeTaken).Str("method", r.Method).Str("path", r.RequestURI).Str("remote address", r.RemoteAddr).Msg("")
-	})
+func newReportRequest(r *http.Request) *frs.ReportRequest {
+	client := frs.ClientFromContext(r.Context())
+	request := &frs.ReportRequest{
+		Method:    r.Method,
+		URL:       r.URL.RequestURI(),
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	}
+	if request.IP == "" {
+		request.IP = r.RemoteAddr
+	}
+	if request.UserAgent == "" {
+		request.UserAgent = r.UserAgent()
+	}
+	return request
 }
 
+// Error writes err as an RFC 7807 Problem when the client accepts
+// application/problem+json and as the legacy ErrorResponse otherwise.
 func Error(rw http.ResponseWriter, r *http.Request, err error) {
 
-	log.Error().Err(err).Msg("")
+	log.Ctx(r.Context()).Error().Err(err).Msg("")
 	errCode, errMessage := frs.ErrorCode(err), frs.ErrorMessage(err)
 
+	// anything that isn't an frs.Error is a bug or an outage worth reporting,
+	// except the client going away or the request running out of time
+	var e *frs.Error
+	if !errors.As(err, &e) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		report := frs.NewErrorReport(r.Context(), err)
+		report.Request = newReportRequest(r)
+		if err := frs.SendReport(r.Context(), report); err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("cannot report error")
+		}
+	}
+
+	var body any = ErrorResponse{Error: errMessage, Fields: frs.ErrorFields(err), RequestID: requestIDOf(r)}
 	rw.Header().Set("Content-Type", "application/json")
+	if acceptsProblem(r) {
+		body = newProblem(r, err)
+		rw.Header().Set("Content-Type", problemContentType)
+	}
+	rw.Header().Add("Vary", "Accept")
 	rw.WriteHeader(ErrorStatusCode(errCode))
 
-	if err := json.NewEncoder(rw).Encode(ErrorResponse{Error: errMessage}); err != nil {
-		log.Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+	if err := json.NewEncoder(rw).Encode(body); err != nil {
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+	}
+}
+
+// requestIDOf falls back to the header for requests that did not pass
+// through the requestID middleware.
+func requestIDOf(r *http.Request) string {
+	if id := frs.RequestIDFromContext(r.Context()); id != "" {
+		return id
 	}
+	return r.Header.Get(requestIDHeader)
 }
 
 type ErrorResponse struct {
 	Error string `json:"error"`
+	// only set on validation errors
+	Fields    []frs.FieldError `json:"fields,omitempty"`
+	RequestID string           `json:"request_id,omitempty"`
 }
 
 type SuccessResponse struct {
 	Data map[string]any `json:"data"`
+	// only set on list responses
+	Meta *Meta `json:"meta,omitempty"`
 }
 
 var codes = map[string]int{
-	frs.EBADREQUEST:   http.StatusBadRequest,
-	frs.EINVALID:      http.StatusBadRequest,
-	frs.EINTERNAL:     http.StatusInternalServerError,
-	frs.ENOTFOUND:     http.StatusNotFound,
-	frs.EUNAUTHORIZED: http.StatusUnauthorized,
+	frs.EBADREQUEST:           http.StatusBadRequest,
+	frs.EINVALID:              http.StatusBadRequest,
+	frs.EINTERNAL:             http.StatusInternalServerError,
+	frs.ENOTFOUND:             http.StatusNotFound,
+	frs.EUNAUTHORIZED:         http.StatusUnauthorized,
+	frs.EFORBIDDEN:            http.StatusForbidden,
+	frs.ERATELIMIT:            http.StatusTooManyRequests,
+	frs.ECONFLICT:             http.StatusConflict,
+	frs.EPRECONDITION:         http.StatusPreconditionFailed,
+	frs.EPRECONDITIONREQUIRED: http.StatusPreconditionRequired,
 }
 
 func ErrorStatusCode(code string) int {
@@ -1,11 +1,89 @@
 package http_test
 
 import (
+	"encoding/json"
+	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+
+	"github.com/TezzBhandari/frs"
+	frshttp "github.com/TezzBhandari/frs/http"
 )
 
 func TestServer(t *testing.T) {
 	httptest.NewRecorder()
 
 }
+
+func TestError_ValidationFields(t *testing.T) {
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/users", nil)
+	frshttp.Error(rw, r, (&frs.User{Username: "jane"}).Validate())
+
+	if rw.Code != http.StatusBadRequest {
+		t.Errorf("status: got: %d, want: %d", rw.Code, http.StatusBadRequest)
+	}
+
+	var body frshttp.ErrorResponse
+	if err := json.NewDecoder(rw.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Fields) != 2 || body.Fields[0].Field != "email" || body.Fields[1].Field != "password" {
+		t.Errorf("fields: got: %+v", body.Fields)
+	}
+	if body.Error != "email required; password required" {
+		t.Errorf("error: got: %q", body.Error)
+	}
+}
+
+func TestError_Problem(t *testing.T) {
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/fund-raiser/42", nil)
+	r.Header.Set("Accept", "application/json;q=0.5, application/problem+json")
+	r.Header.Set("X-Request-ID", "req-1")
+	frshttp.Error(rw, r, frs.Errorf(frs.ENOTFOUND, "fund raiser does not exist"))
+
+	if got := rw.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("content type: got: %q", got)
+	}
+
+	var problem frshttp.Problem
+	if err := json.NewDecoder(rw.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	want := frshttp.Problem{
+		Type:      "urn:frs:problem:not_found",
+		Title:     "Not Found",
+		Status:    http.StatusNotFound,
+		Detail:    "fund raiser does not exist",
+		Instance:  "/api/v1/fund-raiser/42",
+		Code:      frs.ENOTFOUND,
+		RequestID: "req-1",
+	}
+	if !reflect.DeepEqual(problem, want) {
+		t.Errorf("got: %+v, want: %+v", problem, want)
+	}
+}
+
+func TestError_LegacyByDefault(t *testing.T) {
+	for _, accept := range []string{"", "application/json", "*/*", "application/problem+json;q=0"} {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		frshttp.Error(rw, r, frs.Errorf(frs.ENOTFOUND, "user does not exist"))
+
+		if got := rw.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("%q: content type: got: %q", accept, got)
+		}
+		var body map[string]any
+		if err := json.NewDecoder(rw.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body["error"] != "user does not exist" {
+			t.Errorf("%q: body: got: %v", accept, body)
+		}
+	}
+}
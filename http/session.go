@@ -0,0 +1,80 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/utils"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+func (s *Server) registerSessionRoutes(r *mux.Router) {
+	r.HandleFunc("/users/{id}/sessions", s.handleFindSessions).Methods(http.MethodGet)
+	r.HandleFunc("/users/{id}/sessions", s.handleRevokeSessions).Methods(http.MethodDelete)
+	r.HandleFunc("/users/{id}/sessions/{sessionId}", s.handleRevokeSession).Methods(http.MethodDelete)
+}
+
+func (s *Server) handleFindSessions(rw http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.ParseInt(mux.Vars(r)["id"], 0, 64)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EINVALID, utils.InvalidUserIdMsg()))
+		return
+	}
+
+	sessions, err := s.SessionService.FindSessions(r.Context(), userId)
+	if err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(SuccessResponse{
+		Data: map[string]any{
+			"sessions": sessions,
+		},
+	}); err != nil {
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+	}
+}
+
+func (s *Server) handleRevokeSession(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userId, err := strconv.ParseInt(vars["id"], 0, 64)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EINVALID, utils.InvalidUserIdMsg()))
+		return
+	}
+
+	sessionId, err := strconv.ParseInt(vars["sessionId"], 0, 64)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EINVALID, utils.InvalidSessionIdMsg()))
+		return
+	}
+
+	if err := s.SessionService.RevokeSession(r.Context(), userId, sessionId); err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleRevokeSessions(rw http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.ParseInt(mux.Vars(r)["id"], 0, 64)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EINVALID, utils.InvalidUserIdMsg()))
+		return
+	}
+
+	if err := s.SessionService.RevokeSessions(r.Context(), userId); err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/TezzBhandari/frs/http"
+
+// propagator reads W3C traceparent, tracestate and baggage headers so spans
+// continue the trace of the caller.
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// trace starts a server span per request, named by route template like the
+// metrics.
+func (s *Server) trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := routeTemplate(r)
+		ctx, span := s.TracerProvider.Tracer(tracerName).Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("client.address", r.RemoteAddr),
+			),
+		)
+		defer span.End()
+
+		srw := &statusResponseWriter{ResponseWriter: rw}
+		next.ServeHTTP(srw, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", srw.Status()))
+		if srw.Status() >= http.StatusInternalServerError {
+			span.SetStatus(otelcodes.Error, http.StatusText(srw.Status()))
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	s, _ := newFundRaiserServer(t)
+	s.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	req, err := http.NewRequest(http.MethodGet, s.Url()+"/api/v1/fund-raiser/42", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got: %d spans, want: 1", len(spans))
+	}
+	span := spans[0]
+
+	if got, want := span.Name(), "GET /api/v1/fund-raiser/{id}"; got != want {
+		t.Errorf("name: got: %q, want: %q", got, want)
+	}
+	if span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("kind: got: %s", span.SpanKind())
+	}
+	if got, want := span.Parent().TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+		t.Errorf("trace id not propagated: got: %s, want: %s", got, want)
+	}
+	if !span.Parent().IsRemote() {
+		t.Error("parent should be remote")
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, attr := range span.Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	if got := attrs["http.route"].AsString(); got != "/api/v1/fund-raiser/{id}" {
+		t.Errorf("route: got: %q", got)
+	}
+	if got := attrs["http.response.status_code"].AsInt64(); got != int64(resp.StatusCode) {
+		t.Errorf("status: got: %d, want: %d", got, resp.StatusCode)
+	}
+}
@@ -15,10 +15,13 @@ import (
 
 func (s *Server) registerUserRoutes(r *mux.Router) {
 	r.HandleFunc("/users", s.handleCreateUser).Methods(http.MethodPost)
-	r.HandleFunc("/users", s.handleFindUsers).Methods(http.MethodGet)
+	r.HandleFunc("/users", requirePermission(frs.PermReadUsers, s.handleFindUsers)).Methods(http.MethodGet)
 	r.HandleFunc("/users/{id}", s.handleFindUserById).Methods(http.MethodGet)
 	r.HandleFunc("/users/{id}", s.handleDeleteUser).Methods(http.MethodDelete)
 	r.HandleFunc("/users/{id}", s.handleUpdateUser).Methods(http.MethodPut)
+	r.HandleFunc("/users/{id}", s.handlePatchUser).Methods(http.MethodPatch)
+
+	r.HandleFunc("/admin/users/{id}/role", requirePermission(frs.PermAssignRoles, s.handleAssignRole)).Methods(http.MethodPut)
 }
 
 func (s *Server) handleCreateUser(rw http.ResponseWriter, r *http.Request) {
@@ -29,7 +32,7 @@ func (s *Server) handleCreateUser(rw http.ResponseWriter, r *http.Request) {
 		case io.EOF:
 			break
 		default:
-			log.Error().Err(err).Msg("")
+			log.Ctx(r.Context()).Error().Err(err).Msg("")
 			Error(rw, r, frs.Errorf(frs.EINVALID, "invalid json body"))
 			return
 
@@ -46,26 +49,30 @@ func (s *Server) handleCreateUser(rw http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleFindUsers(rw http.ResponseWriter, r *http.Request) {
-	userFilter := &frs.FilterUser{}
-
-	if err := json.NewDecoder(r.Body).Decode(userFilter); err != nil {
-		switch err {
-		// client didn't send request body
-		case io.EOF:
-			break
-		// client sent invalid json body
-		default:
-			Error(rw, r, frs.Errorf(frs.EINVALID, "invalid json body"))
-			return
-		}
+	userFilter, err := parseFilterUser(rw, r)
+	if err != nil {
+		Error(rw, r, err)
+		return
 	}
 
-	users, _, err := s.UserService.FindUsers(r.Context(), userFilter)
+	users, total, err := s.UserService.FindUsers(r.Context(), userFilter)
 	if err != nil {
 		Error(rw, r, err)
 		return
 	}
 
+	p := page{
+		total:  total,
+		limit:  userFilter.Limit,
+		offset: userFilter.Offset,
+		cursor: userFilter.Cursor,
+		size:   len(users),
+	}
+	if len(users) > 0 {
+		last := users[len(users)-1]
+		p.last = &frs.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
 
@@ -73,9 +80,10 @@ func (s *Server) handleFindUsers(rw http.ResponseWriter, r *http.Request) {
 		Data: map[string]any{
 			"users": users,
 		},
+		Meta: newMeta(r, p),
 	})
 	if err != nil {
-		log.Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
 	}
 
 }
@@ -90,10 +98,11 @@ func (s *Server) handleFindUserById(rw http.ResponseWriter, r *http.Request) {
 
 	user, err := s.UserService.FindUserById(r.Context(), userId)
 	if err != nil {
-		log.Error().Err(err).Msg("")
+		log.Ctx(r.Context()).Error().Err(err).Msg("")
 		Error(rw, r, err)
 		return
 	}
+	rw.Header().Set("ETag", etag(user.Version))
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
 	err = json.NewEncoder(rw).Encode(SuccessResponse{
@@ -102,7 +111,7 @@ func (s *Server) handleFindUserById(rw http.ResponseWriter, r *http.Request) {
 		},
 	})
 	if err != nil {
-		log.Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
 
 	}
 }
@@ -117,7 +126,7 @@ func (s *Server) handleDeleteUser(rw http.ResponseWriter, r *http.Request) {
 
 	err = s.UserService.DeleteUser(r.Context(), userId)
 	if err != nil {
-		log.Error().Err(err).Msg("")
+		log.Ctx(r.Context()).Error().Err(err).Msg("")
 		Error(rw, r, err)
 		return
 	}
@@ -132,9 +141,16 @@ func (s *Server) handleUpdateUser(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updateUser := &frs.UpdateUser{}
+	version, err := optionalIfMatchVersion(r)
+	if err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	// PUT replaces the whole profile, omitted fields are cleared
+	user := &frs.User{}
 
-	err = json.NewDecoder(r.Body).Decode(updateUser)
+	err = json.NewDecoder(r.Body).Decode(user)
 	if err != nil {
 		switch err {
 		case io.EOF:
@@ -144,13 +160,58 @@ func (s *Server) handleUpdateUser(rw http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if version != 0 {
+		user.Version = version
+	}
+
+	s.replaceUser(rw, r, userId, user)
+}
 
-	user, err := s.UserService.UpdateUser(r.Context(), userId, *updateUser)
+func (s *Server) handlePatchUser(rw http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	userId, err := strconv.ParseInt(id, 0, 64)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EINVALID, "invalid user id"))
+		return
+	}
+
+	version, err := optionalIfMatchVersion(r)
 	if err != nil {
 		Error(rw, r, err)
 		return
 	}
 
+	current, err := s.UserService.FindUserById(r.Context(), userId)
+	if err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	// don't merge into a version the client hasn't seen
+	if version != 0 && version != current.Version {
+		Error(rw, r, frs.Errorf(frs.EPRECONDITION, utils.StaleVersionMsg("user")))
+		return
+	}
+
+	user := &frs.User{}
+	if err := applyMergePatch(r, current, user); err != nil {
+		Error(rw, r, err)
+		return
+	}
+	// a write landing between the read above and the replace fails with
+	// EPRECONDITION instead of being overwritten
+	user.Version = current.Version
+	s.replaceUser(rw, r, userId, user)
+}
+
+func (s *Server) replaceUser(rw http.ResponseWriter, r *http.Request, id int64, user *frs.User) {
+	user, err := s.UserService.ReplaceUser(r.Context(), id, user)
+	if err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	rw.Header().Set("ETag", etag(user.Version))
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
 
@@ -161,6 +222,40 @@ func (s *Server) handleUpdateUser(rw http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		log.Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
+	}
+}
+
+func (s *Server) handleAssignRole(rw http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	userId, err := strconv.ParseInt(id, 0, 64)
+	if err != nil {
+		Error(rw, r, frs.Errorf(frs.EINVALID, utils.InvalidUserIdMsg()))
+		return
+	}
+
+	body := &struct {
+		Role frs.Role `json:"role"`
+	}{}
+	if err := ReadJsonBody(r.Body, body); err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	user, err := s.UserService.AssignRole(r.Context(), userId, body.Role)
+	if err != nil {
+		Error(rw, r, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(rw).Encode(SuccessResponse{
+		Data: map[string]any{
+			"user": user,
+		},
+	}); err != nil {
+		log.Ctx(r.Context()).Error().Err(fmt.Errorf("%s %w", utils.FailedResponseMsg(), err)).Msg("")
 	}
 }
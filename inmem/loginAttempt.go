@@ -0,0 +1,76 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+)
+
+var _ frs.LoginAttemptStore = (*LoginAttemptStore)(nil)
+
+// records untouched for this long are dropped on the next sweep
+const DefaultLoginAttemptTTL = 24 * time.Hour
+
+// LoginAttemptStore keeps failure records in process memory. Counts are not
+// shared between instances, use the postgres store for that.
+type LoginAttemptStore struct {
+	mu        sync.Mutex
+	attempts  map[string]frs.LoginAttempts
+	lastSweep time.Time
+
+	TTL time.Duration
+	Now func() time.Time
+}
+
+func NewLoginAttemptStore() *LoginAttemptStore {
+	return &LoginAttemptStore{
+		attempts: make(map[string]frs.LoginAttempts),
+		TTL:      DefaultLoginAttemptTTL,
+		Now:      time.Now,
+	}
+}
+
+func (s *LoginAttemptStore) FindLoginAttempts(ctx context.Context, key string) (*frs.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts := s.attempts[key]
+	return &attempts, nil
+}
+
+func (s *LoginAttemptStore) UpdateLoginAttempts(ctx context.Context, key string, fn func(*frs.LoginAttempts)) (*frs.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+
+	attempts := s.attempts[key]
+	fn(&attempts)
+	s.attempts[key] = attempts
+	return &attempts, nil
+}
+
+func (s *LoginAttemptStore) DeleteLoginAttempts(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+// sweep drops stale records at most once per TTL so memory stays bounded.
+func (s *LoginAttemptStore) sweep() {
+	now := s.Now()
+	if now.Sub(s.lastSweep) < s.TTL {
+		return
+	}
+	s.lastSweep = now
+
+	for key, attempts := range s.attempts {
+		if now.Sub(attempts.LastFailure) > s.TTL && now.After(attempts.BlockedUntil) {
+			delete(s.attempts, key)
+		}
+	}
+}
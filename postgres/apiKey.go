@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ frs.APIKeyService = (*APIKeyService)(nil)
+
+// every generated key starts with this so they are easy to spot in logs and
+// secret scanners
+const apiKeyPrefix = "frs_"
+
+type APIKeyService struct {
+	db *DB
+}
+
+func NewAPIKeyService(db *DB) *APIKeyService {
+	return &APIKeyService{db: db}
+}
+
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, key *frs.APIKey) error {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := frs.AuthorizeOwner(ctx, key.UserID, frs.PermWriteProfile, frs.PermWriteUsers); err != nil {
+		return err
+	}
+
+	if err := frs.AuthorizeGrant(ctx, key.Scopes); err != nil {
+		return err
+	}
+
+	if err := createAPIKey(ctx, tx, key); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *APIKeyService) FindAPIKeys(ctx context.Context, userID int64) ([]*frs.APIKey, error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := frs.AuthorizeOwner(ctx, userID, frs.PermWriteProfile, frs.PermWriteUsers); err != nil {
+		return nil, err
+	}
+
+	return findAPIKeys(ctx, tx, userID)
+}
+
+// return NOTFOUND Error
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, userID, id int64) error {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := frs.AuthorizeOwner(ctx, userID, frs.PermWriteProfile, frs.PermWriteUsers); err != nil {
+		return err
+	}
+
+	if err := revokeAPIKey(ctx, tx, userID, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// return UNAUTHORIZED Error if the key is unknown, revoked or expired
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, plainKey string) (*frs.User, *frs.APIKey, error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	key, err := findAPIKeyByHash(ctx, tx, hashAPIKey(plainKey))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if key.ExpiresAt != nil && !tx.Now.Before(*key.ExpiresAt) {
+		return nil, nil, frs.Errorf(frs.EUNAUTHORIZED, "api key expired")
+	}
+
+	user, err := findUserById(ctx, tx, key.UserID)
+	if err != nil {
+		if frs.ErrorCode(err) == frs.ENOTFOUND {
+			return nil, nil, frs.Errorf(frs.EUNAUTHORIZED, "invalid api key")
+		}
+		return nil, nil, err
+	}
+
+	lastUsedAt := tx.Now
+	key.LastUsedAt = &lastUsedAt
+	touchAPIKeyQuery := `UPDATE api_keys SET last_used_at = $1 WHERE id = $2;`
+	if _, err := tx.Exec(ctx, touchAPIKeyQuery, key.LastUsedAt, key.ID); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return user, key, nil
+}
+
+func createAPIKey(ctx context.Context, tx *Tx, key *frs.APIKey) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(tx.Now) {
+		return frs.Errorf(frs.EBADREQUEST, "api key expiry must be in the future")
+	}
+
+	user, err := findUserById(ctx, tx, key.UserID)
+	if err != nil {
+		return err
+	}
+
+	// a key can never do more than its owner
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		if !user.Role.Can(scope) {
+			return frs.Errorf(frs.EFORBIDDEN, "role %s cannot grant scope %q", user.Role, scope)
+		}
+		scopes = append(scopes, string(scope))
+	}
+
+	buf := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return err
+	}
+
+	key.ID, err = tx.db.generateID()
+	if err != nil {
+		return err
+	}
+	key.Key = apiKeyPrefix + hex.EncodeToString(buf)
+	key.Prefix = key.Key[:len(apiKeyPrefix)+8]
+	key.CreatedAt = tx.Now
+	key.LastUsedAt = nil
+
+	insertAPIKeyQuery := `
+		INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+	`
+	_, err = tx.Exec(ctx, insertAPIKeyQuery, key.ID, key.UserID, key.Name, key.Prefix, hashAPIKey(key.Key), scopes, key.ExpiresAt, key.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func findAPIKeys(ctx context.Context, tx *Tx, userID int64) ([]*frs.APIKey, error) {
+	findAPIKeysQuery := `
+		SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at
+		FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC;
+	`
+	rows, err := tx.Query(ctx, findAPIKeysQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]*frs.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func findAPIKeyByHash(ctx context.Context, tx *Tx, keyHash string) (*frs.APIKey, error) {
+	findAPIKeyQuery := `
+		SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL;
+	`
+	key, err := scanAPIKey(tx.QueryRow(ctx, findAPIKeyQuery, keyHash))
+	if err != nil {
+		switch err {
+		case pgx.ErrNoRows:
+			return nil, frs.Errorf(frs.EUNAUTHORIZED, "invalid api key")
+		default:
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func revokeAPIKey(ctx context.Context, tx *Tx, userID, id int64) error {
+	revokeAPIKeyQuery := `UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL;`
+	tag, err := tx.Exec(ctx, revokeAPIKeyQuery, tx.Now, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return frs.Errorf(frs.ENOTFOUND, utils.DoesNotExistMsg("api key"))
+	}
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (*frs.APIKey, error) {
+	var key frs.APIKey
+	var scopes []string
+	var expiresAt, lastUsedAt *time.Time
+
+	if err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &scopes, &expiresAt, &lastUsedAt, &key.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	key.ExpiresAt, key.LastUsedAt = expiresAt, lastUsedAt
+	key.Scopes = make([]frs.Permission, 0, len(scopes))
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, frs.Permission(scope))
+	}
+	return &key, nil
+}
+
+// keys carry 256 bits of entropy so a plain sha256 is enough to store them
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
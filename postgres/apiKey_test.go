@@ -0,0 +1,93 @@
+package postgres_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	p "github.com/TezzBhandari/frs/postgres"
+)
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	db := MustOpenDB(t)
+	s := p.NewAPIKeyService(db)
+	user := MustCreateUser(t, db, "owner", frs.RoleAdmin)
+	ctx := frs.NewContextWithUser(context.Background(), user)
+
+	t.Run("StoresHash", func(t *testing.T) {
+		key := &frs.APIKey{UserID: user.ID, Name: "ci", Scopes: []frs.Permission{frs.PermWriteProfile}}
+		if err := s.CreateAPIKey(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+
+		var keyHash string
+		conn := MustConnect(t, db.DSN)
+		if err := conn.QueryRow(context.Background(), `SELECT key_hash FROM api_keys WHERE id = $1`, key.ID).Scan(&keyHash); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256([]byte(key.Key))
+		if keyHash == key.Key || keyHash != hex.EncodeToString(sum[:]) {
+			t.Errorf("got: %q, want sha256 of the key", keyHash)
+		}
+	})
+
+	t.Run("ErrEscalation", func(t *testing.T) {
+		keyCtx := frs.NewContextWithAPIKey(ctx, &frs.APIKey{UserID: user.ID, Scopes: []frs.Permission{frs.PermWriteProfile}})
+		key := &frs.APIKey{UserID: user.ID, Name: "escalate", Scopes: []frs.Permission{frs.PermWriteProfile, frs.PermWriteUsers}}
+		if got := frs.ErrorCode(s.CreateAPIKey(keyCtx, key)); got != frs.EFORBIDDEN {
+			t.Errorf("got: %q, want: %q", got, frs.EFORBIDDEN)
+		}
+	})
+}
+
+func TestAPIKeyService_AuthenticateAPIKey(t *testing.T) {
+	db := MustOpenDB(t)
+	s := p.NewAPIKeyService(db)
+	user := MustCreateUser(t, db, "owner", frs.RoleDonor)
+	ctx := frs.NewContextWithUser(context.Background(), user)
+
+	t.Run("OK", func(t *testing.T) {
+		key := &frs.APIKey{UserID: user.ID, Name: "ok", Scopes: []frs.Permission{frs.PermWriteProfile}}
+		if err := s.CreateAPIKey(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+
+		got, _, err := s.AuthenticateAPIKey(context.Background(), key.Key)
+		if err != nil {
+			t.Fatal(err)
+		} else if got.ID != user.ID {
+			t.Errorf("got: user %d, want: %d", got.ID, user.ID)
+		}
+	})
+
+	t.Run("ErrRevoked", func(t *testing.T) {
+		key := &frs.APIKey{UserID: user.ID, Name: "revoked", Scopes: []frs.Permission{frs.PermWriteProfile}}
+		if err := s.CreateAPIKey(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RevokeAPIKey(ctx, user.ID, key.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := s.AuthenticateAPIKey(context.Background(), key.Key); frs.ErrorCode(err) != frs.EUNAUTHORIZED {
+			t.Errorf("got: %v, want: %q", err, frs.EUNAUTHORIZED)
+		}
+	})
+
+	t.Run("ErrExpired", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		key := &frs.APIKey{UserID: user.ID, Name: "expired", Scopes: []frs.Permission{frs.PermWriteProfile}, ExpiresAt: &expiresAt}
+		if err := s.CreateAPIKey(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+
+		db.Now = func() time.Time { return expiresAt.Add(time.Second) }
+		defer func() { db.Now = time.Now }()
+		if _, _, err := s.AuthenticateAPIKey(context.Background(), key.Key); frs.ErrorCode(err) != frs.EUNAUTHORIZED {
+			t.Errorf("got: %v, want: %q", err, frs.EUNAUTHORIZED)
+		}
+	})
+}
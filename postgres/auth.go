@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var _ frs.AuthService = (*AuthService)(nil)
+
+const DefaultTokenTTL = 24 * time.Hour
+
+type AuthService struct {
+	db *DB
+
+	// key used to sign access tokens
+	Secret   []byte
+	TokenTTL time.Duration
+}
+
+func NewAuthService(db *DB, secret []byte) *AuthService {
+	return &AuthService{
+		db:       db,
+		Secret:   secret,
+		TokenTTL: DefaultTokenTTL,
+	}
+}
+
+// return UNAUTHORIZED Error on bad credentials
+func (s *AuthService) Login(ctx context.Context, creds *frs.Credentials) (*frs.Auth, error) {
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	userID, err := verifyPassword(ctx, tx, creds.Email, creds.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := s.newAuth(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// newAuth starts a session for the user and signs a token for it.
+func (s *AuthService) newAuth(ctx context.Context, tx *Tx, userID int64) (*frs.Auth, error) {
+	client := frs.ClientFromContext(ctx)
+	session := &frs.Session{
+		UserID:    userID,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		ExpiresAt: tx.Now.Add(s.TokenTTL),
+	}
+
+	if err := createSession(ctx, tx, session); err != nil {
+		return nil, err
+	}
+
+	return &frs.Auth{
+		ID:          userID,
+		SessionID:   session.ID,
+		AccessToken: s.signToken(session.ID, session.ExpiresAt),
+		ExpiresAt:   session.ExpiresAt,
+	}, nil
+}
+
+// return UNAUTHORIZED Error if the token is invalid, expired or its session
+// was revoked
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*frs.User, *frs.Session, error) {
+	sessionID, expiresAt, err := s.parseToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if !tx.Now.Before(expiresAt) {
+		return nil, nil, frs.Errorf(frs.EUNAUTHORIZED, "access token expired")
+	}
+
+	session, err := findActiveSession(ctx, tx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := findUserById(ctx, tx, session.UserID)
+	if err != nil {
+		if frs.ErrorCode(err) == frs.ENOTFOUND {
+			return nil, nil, frs.Errorf(frs.EUNAUTHORIZED, "invalid access token")
+		}
+		return nil, nil, err
+	}
+
+	if err := touchSession(ctx, tx, session, frs.ClientFromContext(ctx)); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	session.Current = true
+	return user, session, nil
+}
+
+// signToken returns "<session id>.<expiry>.<signature>".
+func (s *AuthService) signToken(sessionID int64, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d.%d", sessionID, expiresAt.Unix())
+	return payload + "." + s.sign(payload)
+}
+
+func (s *AuthService) parseToken(token string) (int64, time.Time, error) {
+	invalid := frs.Errorf(frs.EUNAUTHORIZED, "invalid access token")
+
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return 0, time.Time{}, invalid
+	}
+
+	payload, signature := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return 0, time.Time{}, invalid
+	}
+
+	id, exp, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, time.Time{}, invalid
+	}
+
+	sessionID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, invalid
+	}
+
+	expiresAt, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, invalid
+	}
+
+	return sessionID, time.Unix(expiresAt, 0), nil
+}
+
+func (s *AuthService) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func verifyPassword(ctx context.Context, tx *Tx, email, password string) (int64, error) {
+	var id int64
+	var passwordHash *string
+
+	// matched like identity emails, see findUserIDByEmail
+	selectPasswordQuery := `SELECT id, password FROM users WHERE LOWER(email) = LOWER($1);`
+	err := tx.QueryRow(ctx, selectPasswordQuery, email).Scan(&id, &passwordHash)
+	if err != nil {
+		switch err {
+		case pgx.ErrNoRows:
+			return 0, frs.Errorf(frs.EUNAUTHORIZED, "invalid email or password")
+		default:
+			return 0, err
+		}
+	}
+
+	// users created through an identity provider have no password
+	if passwordHash == nil {
+		return 0, frs.Errorf(frs.EUNAUTHORIZED, "invalid email or password")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(*passwordHash), []byte(password)); err != nil {
+		return 0, frs.Errorf(frs.EUNAUTHORIZED, "invalid email or password")
+	}
+
+	return id, nil
+}
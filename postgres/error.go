@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes translated by FormatError
+const (
+	notNullViolation     = "23502"
+	foreignKeyViolation  = "23503"
+	uniqueViolation      = "23505"
+	checkViolation       = "23514"
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// constraintMessages holds client facing messages for named constraints, the
+// names postgres gives the UNIQUE and REFERENCES clauses of the migrations.
+// Constraints missing here get a generic message.
+var constraintMessages = map[string]struct {
+	// the request field at fault, if any
+	field   string
+	message string
+}{
+	"users_username_key":              {"username", "username already exists"},
+	"users_email_key":                 {"email", "email already exists"},
+	"users_email_lower_key":           {"email", "email already exists"},
+	"api_keys_key_hash_key":           {"", "api key already exists"},
+	"identities_provider_subject_key": {"", "identity already linked"},
+	"api_keys_user_id_fkey":           {"", "user does not exist"},
+	"identities_user_id_fkey":         {"", "user does not exist"},
+	"sessions_user_id_fkey":           {"", "user does not exist"},
+}
+
+// FormatError translates constraint violations and serialization failures
+// into frs errors. Every other error is returned unchanged.
+func FormatError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	constraint, ok := constraintMessages[pgErr.ConstraintName]
+	switch pgErr.Code {
+	case uniqueViolation:
+		if !ok {
+			return frs.Errorf(frs.ECONFLICT, "resource already exists")
+		}
+		err := frs.Errorf(frs.ECONFLICT, "%s", constraint.message)
+		if constraint.field != "" {
+			err.Fields = []frs.FieldError{{Field: constraint.field, Code: frs.FieldTaken, Message: constraint.message}}
+		}
+		return err
+	case foreignKeyViolation:
+		if !ok {
+			return frs.Errorf(frs.EINVALID, "referenced resource does not exist")
+		}
+		return frs.Errorf(frs.EINVALID, "%s", constraint.message)
+	case checkViolation:
+		if !ok {
+			return frs.Errorf(frs.EINVALID, "invalid value")
+		}
+		return frs.Errorf(frs.EINVALID, "%s", constraint.message)
+	case notNullViolation:
+		return frs.Errorf(frs.EINVALID, "%s is required", pgErr.ColumnName)
+	case serializationFailure, deadlockDetected:
+		return frs.Errorf(frs.ECONFLICT, "concurrent update, please retry")
+	default:
+		return err
+	}
+}
+
+// row and rows translate errors surfacing when results are read.
+type row struct {
+	pgx.Row
+}
+
+func (r row) Scan(dest ...any) error {
+	return FormatError(r.Row.Scan(dest...))
+}
+
+type rows struct {
+	pgx.Rows
+}
+
+func (r rows) Err() error {
+	return FormatError(r.Rows.Err())
+}
+
+func (r rows) Scan(dest ...any) error {
+	return FormatError(r.Rows.Scan(dest...))
+}
@@ -0,0 +1,148 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/TezzBhandari/frs"
+	p "github.com/TezzBhandari/frs/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestFormatError(t *testing.T) {
+	for _, tt := range []struct {
+		err     error
+		code    string
+		message string
+	}{
+		{&pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}, frs.ECONFLICT, "email already exists"},
+		{&pgconn.PgError{Code: "23505", ConstraintName: "fundraisers_pkey"}, frs.ECONFLICT, "resource already exists"},
+		{fmt.Errorf("update user: %w", &pgconn.PgError{Code: "23505", ConstraintName: "users_username_key"}), frs.ECONFLICT, "username already exists"},
+		{&pgconn.PgError{Code: "23503", ConstraintName: "sessions_user_id_fkey"}, frs.EINVALID, "user does not exist"},
+		{&pgconn.PgError{Code: "23514", ConstraintName: "fundraisers_target_check"}, frs.EINVALID, "invalid value"},
+		{&pgconn.PgError{Code: "23502", ColumnName: "title"}, frs.EINVALID, "title is required"},
+		{&pgconn.PgError{Code: "40001"}, frs.ECONFLICT, "concurrent update, please retry"},
+	} {
+		err := p.FormatError(tt.err)
+		if code, message := frs.ErrorCode(err), frs.ErrorMessage(err); code != tt.code || message != tt.message {
+			t.Errorf("%v: got: %s %q, want: %s %q", tt.err, code, message, tt.code, tt.message)
+		}
+	}
+}
+
+func TestFormatError_Passthrough(t *testing.T) {
+	if err := p.FormatError(nil); err != nil {
+		t.Errorf("nil: got: %v", err)
+	}
+	if err := p.FormatError(pgx.ErrNoRows); err != pgx.ErrNoRows {
+		t.Errorf("no rows: got: %v", err)
+	}
+
+	syntax := &pgconn.PgError{Code: "42601"}
+	if err := p.FormatError(syntax); !errors.Is(err, syntax) {
+		t.Errorf("syntax error: got: %v", err)
+	}
+}
+
+// matches the tables, the constraint clauses postgres names itself and the
+// unique indexes named in the migrations
+var (
+	createTableRegex = regexp.MustCompile(`(?i)^CREATE TABLE (?:IF NOT EXISTS )?(\w+)`)
+	uniqueIndexRegex = regexp.MustCompile(`(?i)^CREATE UNIQUE INDEX (?:IF NOT EXISTS )?(\w+)`)
+	columnRegex      = regexp.MustCompile(`^(\w+) .*\b(UNIQUE|REFERENCES)\b`)
+	tableUniqueRegex = regexp.MustCompile(`^UNIQUE \(([\w, ]+)\)`)
+)
+
+// shippedConstraints returns the names postgres gives the UNIQUE and
+// REFERENCES clauses of the migrations, <table>_<columns>_key or _fkey, and
+// the names of the unique indexes.
+func shippedConstraints(t *testing.T) map[string]string {
+	migrations, err := p.ParseMigrations(os.DirFS("migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	constraints := make(map[string]string)
+	for _, m := range migrations {
+		var table string
+		for _, line := range strings.Split(m.Up, "\n") {
+			line = strings.TrimSpace(line)
+			if match := createTableRegex.FindStringSubmatch(line); match != nil {
+				table = match[1]
+			} else if match := uniqueIndexRegex.FindStringSubmatch(line); match != nil {
+				constraints[match[1]] = "23505"
+			} else if strings.HasPrefix(line, ")") {
+				table = ""
+			} else if table == "" {
+				continue
+			} else if match := tableUniqueRegex.FindStringSubmatch(line); match != nil {
+				columns := strings.ReplaceAll(strings.ReplaceAll(match[1], " ", ""), ",", "_")
+				constraints[table+"_"+columns+"_key"] = "23505"
+			} else if match := columnRegex.FindStringSubmatch(line); match != nil {
+				if match[2] == "UNIQUE" {
+					constraints[table+"_"+match[1]+"_key"] = "23505"
+				} else {
+					constraints[table+"_"+match[1]+"_fkey"] = "23503"
+				}
+			}
+		}
+	}
+	return constraints
+}
+
+// every constraint in the migrations gets a specific message, a renamed
+// column or a new constraint must be added to FormatError
+func TestFormatError_ShippedConstraints(t *testing.T) {
+	constraints := shippedConstraints(t)
+	if constraints["users_email_key"] == "" || constraints["users_email_lower_key"] == "" {
+		t.Fatalf("got: %v, want users_email_key and users_email_lower_key", constraints)
+	}
+
+	for name, code := range constraints {
+		err := p.FormatError(&pgconn.PgError{Code: code, ConstraintName: name})
+		switch frs.ErrorMessage(err) {
+		case "resource already exists", "referenced resource does not exist":
+			t.Errorf("%s: got generic message %q", name, frs.ErrorMessage(err))
+		}
+	}
+
+	err := p.FormatError(&pgconn.PgError{Code: constraints["users_email_key"], ConstraintName: "users_email_key"})
+	want := []frs.FieldError{{Field: "email", Code: frs.FieldTaken, Message: "email already exists"}}
+	if frs.ErrorCode(err) != frs.ECONFLICT || !reflect.DeepEqual(frs.ErrorFields(err), want) {
+		t.Errorf("users_email_key: got: %s %+v, want: %s %+v", frs.ErrorCode(err), frs.ErrorFields(err), frs.ECONFLICT, want)
+	}
+}
+
+func TestUserService_CreateUser_DuplicateEmail(t *testing.T) {
+	db := MustOpenDB(t)
+	s := p.NewUserService(db)
+	MustCreateUser(t, db, "jane", frs.RoleDonor)
+
+	err := s.CreateUser(context.Background(), &frs.User{Username: "janet", Email: "jane@example.com", Password: "password"})
+	if frs.ErrorCode(err) != frs.ECONFLICT {
+		t.Fatalf("got: %v, want: %q", err, frs.ECONFLICT)
+	}
+	if fields := frs.ErrorFields(err); len(fields) != 1 || fields[0].Field != "email" || fields[0].Code != frs.FieldTaken {
+		t.Errorf("fields: got: %+v, want email taken", fields)
+	}
+
+	// emails are stored lowercase, another case is the same address
+	err = s.CreateUser(context.Background(), &frs.User{Username: "janet", Email: "Jane@Example.com", Password: "password"})
+	if frs.ErrorCode(err) != frs.ECONFLICT {
+		t.Errorf("other case: got: %v, want: %q", err, frs.ECONFLICT)
+	}
+
+	user := &frs.User{Username: "john", Email: "John@Example.com", Password: "password"}
+	if err := s.CreateUser(context.Background(), user); err != nil {
+		t.Fatal(err)
+	} else if user.Email != "john@example.com" {
+		t.Errorf("email: got: %q, want: %q", user.Email, "john@example.com")
+	}
+}
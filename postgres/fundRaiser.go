@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"html"
 	"strings"
 
 	"github.com/TezzBhandari/frs"
@@ -29,17 +30,31 @@ func (fr *FundRaiserService) CreateFundRaiser(ctx context.Context, fundRaiser *f
 
 	defer tx.Rollback(ctx)
 
-	fundRaiser.ID = fr.db.snowflake.Generate().Int64()
+	if err := frs.Authorize(ctx, frs.PermWriteFundRaisers); err != nil {
+		return err
+	}
+
+	fundRaiser.ID, err = fr.db.generateID()
+	if err != nil {
+		return err
+	}
+	fundRaiser.OrganizerID = frs.UserIDFromContext(ctx)
+	fundRaiser.AmountRaised = 0
 	fundRaiser.CreatedAt = tx.Now
 	fundRaiser.UpdatedAt = fundRaiser.CreatedAt
+	fundRaiser.Version = 1
 
-	log.Debug().Msg("hi there fund raising works")
+	log.Ctx(ctx).Debug().Msg("hi there fund raising works")
 
 	err = createFundRaiser(ctx, tx, fundRaiser)
 	if err != nil {
 		return err
 	}
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	fundRaisersCreatedCount.Inc()
+	return nil
 }
 
 func (fr *FundRaiserService) FindFundRaiser(ctx context.Context, filterFundRaiser *frs.FilterFundRaiser) ([]*frs.FundRaiser, int, error) {
@@ -81,6 +96,14 @@ func (fr *FundRaiserService) UpdateFundRaiser(ctx context.Context, id int64, upd
 		return nil, err
 	}
 
+	if err := frs.AuthorizeOwner(ctx, fundRaiser.OrganizerID, frs.PermWriteFundRaisers, frs.PermManageFundRaisers); err != nil {
+		return nil, err
+	}
+
+	if updFundRaiser.Version != nil && *updFundRaiser.Version != fundRaiser.Version {
+		return nil, frs.Errorf(frs.EPRECONDITION, utils.StaleVersionMsg("fundraiser"))
+	}
+
 	if updFundRaiser.Title != nil {
 		fundRaiser.Title = *updFundRaiser.Title
 	}
@@ -93,7 +116,55 @@ func (fr *FundRaiserService) UpdateFundRaiser(ctx context.Context, id int64, upd
 	if updFundRaiser.TargetAmount != nil {
 		fundRaiser.TargetAmount = *updFundRaiser.TargetAmount
 	}
+	if updFundRaiser.EndsAt != nil {
+		fundRaiser.EndsAt = updFundRaiser.EndsAt
+	}
+
+	fundRaiser.UpdatedAt = tx.Now
+
+	fundRaiser, err = updateFundRaiser(ctx, tx, id, fundRaiser)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return fundRaiser, nil
+}
+
+func (fr *FundRaiserService) ReplaceFundRaiser(ctx context.Context, id int64, replacement *frs.FundRaiser) (*frs.FundRaiser, error) {
+	tx, err := fr.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
 
+	fundRaiser, err := findFundRaiserById(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// don't tell a caller who may not write the fundraiser what is wrong with it
+	if err := frs.AuthorizeOwner(ctx, fundRaiser.OrganizerID, frs.PermWriteFundRaisers, frs.PermManageFundRaisers); err != nil {
+		return nil, err
+	}
+
+	if err := replacement.Validate(); err != nil {
+		return nil, err
+	}
+
+	if replacement.Version != 0 && replacement.Version != fundRaiser.Version {
+		return nil, frs.Errorf(frs.EPRECONDITION, utils.StaleVersionMsg("fundraiser"))
+	}
+
+	// id, organizer and amounts raised are not writable
+	fundRaiser.Title = replacement.Title
+	fundRaiser.Story = replacement.Story
+	fundRaiser.CoverImg = replacement.CoverImg
+	fundRaiser.TargetAmount = replacement.TargetAmount
+	fundRaiser.EndsAt = replacement.EndsAt
 	fundRaiser.UpdatedAt = tx.Now
 
 	fundRaiser, err = updateFundRaiser(ctx, tx, id, fundRaiser)
@@ -108,13 +179,13 @@ func (fr *FundRaiserService) UpdateFundRaiser(ctx context.Context, id int64, upd
 	return fundRaiser, nil
 }
 
-func (fr *FundRaiserService) DeleteFundRaiser(ctx context.Context, id int64) error {
+func (fr *FundRaiserService) DeleteFundRaiser(ctx context.Context, id int64, version int) error {
 	tx, err := fr.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
-	if err = deleteFundRaiser(ctx, tx, id); err != nil {
+	if err = deleteFundRaiser(ctx, tx, id, version); err != nil {
 		return err
 	}
 	return tx.Commit(ctx)
@@ -127,11 +198,11 @@ func createFundRaiser(ctx context.Context, tx *Tx, fundRaiser *frs.FundRaiser) e
 	}
 
 	insertFundRaiserQuery := `
-		INSERT INTO fundraisers (id, title, story, cover_img, target_amount, created_at, updated_at)
-		VALUES($1, $2, $3, $4, $5, $6, $7);
+		INSERT INTO fundraisers (id, title, story, cover_img, target_amount, ends_at, organizer_id, created_at, updated_at, version)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
 	`
 
-	_, err := tx.Exec(ctx, insertFundRaiserQuery, fundRaiser.ID, fundRaiser.Title, fundRaiser.Story, fundRaiser.CoverImg, fundRaiser.TargetAmount, fundRaiser.CreatedAt, fundRaiser.UpdatedAt)
+	_, err := tx.Exec(ctx, insertFundRaiserQuery, fundRaiser.ID, fundRaiser.Title, fundRaiser.Story, fundRaiser.CoverImg, fundRaiser.TargetAmount, fundRaiser.EndsAt, fundRaiser.OrganizerID, fundRaiser.CreatedAt, fundRaiser.UpdatedAt, fundRaiser.Version)
 	if err != nil {
 		return err
 	}
@@ -139,7 +210,42 @@ func createFundRaiser(ctx context.Context, tx *Tx, fundRaiser *frs.FundRaiser) e
 	return nil
 }
 
+// fundRaiserSorts maps the public sort keys to the column they order by and
+// the default direction. Only these columns ever reach the ORDER BY clause.
+var fundRaiserSorts = map[string]struct {
+	column string
+	order  string
+}{
+	frs.SortCreatedAt:    {"created_at", frs.SortDesc},
+	frs.SortTargetAmount: {"target_amount", frs.SortDesc},
+	frs.SortEndingSoon:   {"ends_at", frs.SortAsc},
+	frs.SortRelevance:    {"rank", frs.SortDesc},
+}
+
+// ts_headline wraps matches in these control characters instead of <mark>
+// tags, the snippet is html escaped before they are turned into tags so user
+// supplied markup in the title or story can't get through. Stray markers in
+// the text itself are removed first.
+const (
+	snippetStartSel = "\x02"
+	snippetStopSel  = "\x03"
+)
+
+// options passed to ts_headline for search result snippets
+const fundRaiserSnippetOptions = "StartSel=" + snippetStartSel + ", StopSel=" + snippetStopSel + ", MaxWords=35, MinWords=15, MaxFragments=2"
+
+var snippetReplacer = strings.NewReplacer(snippetStartSel, "<mark>", snippetStopSel, "</mark>")
+
+// formatSnippet returns the ts_headline output as html with matches marked.
+func formatSnippet(headline string) string {
+	return snippetReplacer.Replace(html.EscapeString(headline))
+}
+
 func findFundRaiser(ctx context.Context, tx *Tx, filterFundRaiser *frs.FilterFundRaiser) ([]*frs.FundRaiser, int, error) {
+	if err := filterFundRaiser.Validate(); err != nil {
+		return nil, 0, err
+	}
+
 	where := []string{"1 = 1"}
 	args := []any{}
 	i := 1
@@ -149,6 +255,12 @@ func findFundRaiser(ctx context.Context, tx *Tx, filterFundRaiser *frs.FilterFun
 		i++
 	}
 
+	if filterFundRaiser.IDs != nil {
+		where = append(where, fmt.Sprintf("id = ANY($%d)", i))
+		args = append(args, filterFundRaiser.IDs)
+		i++
+	}
+
 	if filterFundRaiser.Title != nil {
 		where = append(where, fmt.Sprintf("title = $%d", i))
 		args = append(args, *filterFundRaiser.Title)
@@ -161,35 +273,102 @@ func findFundRaiser(ctx context.Context, tx *Tx, filterFundRaiser *frs.FilterFun
 		i++
 	}
 
+	if filterFundRaiser.CreatedAfter != nil {
+		where = append(where, fmt.Sprintf("created_at > $%d", i))
+		args = append(args, *filterFundRaiser.CreatedAfter)
+		i++
+	}
+
+	if filterFundRaiser.CreatedBefore != nil {
+		where = append(where, fmt.Sprintf("created_at < $%d", i))
+		args = append(args, *filterFundRaiser.CreatedBefore)
+		i++
+	}
+
+	if filterFundRaiser.TargetMin != nil {
+		where = append(where, fmt.Sprintf("target_amount >= $%d", i))
+		args = append(args, *filterFundRaiser.TargetMin)
+		i++
+	}
+
+	if filterFundRaiser.TargetMax != nil {
+		where = append(where, fmt.Sprintf("target_amount <= $%d", i))
+		args = append(args, *filterFundRaiser.TargetMax)
+		i++
+	}
+
+	// snippet and rank are only computed for full text searches
+	searchColumns := `'', 0::real`
+	if filterFundRaiser.Query != nil {
+		where = append(where, fmt.Sprintf("search @@ websearch_to_tsquery('english', $%d)", i))
+		searchColumns = fmt.Sprintf(
+			`ts_headline('english', translate(concat_ws(' ', title, story), '%s', ''), websearch_to_tsquery('english', $%d), '%s'), ts_rank(search, websearch_to_tsquery('english', $%d))`,
+			snippetStartSel+snippetStopSel, i, fundRaiserSnippetOptions, i,
+		)
+		args = append(args, *filterFundRaiser.Query)
+		i++
+	}
+
 	whereClause := strings.Join(where, " AND ")
 
+	// total number of matches, ignoring limit and offset
+	var n int
+	countFundRaiserQuery := `SELECT COUNT(*) FROM fundraisers WHERE ` + whereClause
+	if err := tx.QueryRow(ctx, countFundRaiserQuery, args...).Scan(&n); err != nil {
+		return nil, 0, err
+	}
+
+	sort := fundRaiserSorts[filterFundRaiser.SortKey()]
+	if filterFundRaiser.Order != "" {
+		sort.order = filterFundRaiser.Order
+	}
+
+	// cursors are keyed on created_at so they only work with that order
+	if filterFundRaiser.Cursor != "" && sort.column != "created_at" {
+		return nil, 0, frs.Errorf(frs.EINVALID, "cursor can only be used when sorting by %s", frs.SortCreatedAt)
+	}
+
+	whereClause, args, err := formatCursor(whereClause, args, filterFundRaiser.Cursor, filterFundRaiser.Offset, sort.order)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	direction := "DESC NULLS LAST"
+	if sort.order == frs.SortAsc {
+		direction = "ASC NULLS LAST"
+	}
+
 	findFundRaiserQuery := `
-		SELECT id, title, story, target_amount, cover_img, created_at, updated_at FROM fundraisers WHERE
+		SELECT id, title, story, target_amount, amount_raised, ends_at, cover_img, COALESCE(organizer_id, 0), created_at, updated_at, version,
+			` + searchColumns + ` AS rank FROM fundraisers WHERE
 	` + whereClause + `
-		ORDER BY created_at DESC
+		ORDER BY ` + sort.column + ` ` + direction + `, id ` + direction + `
 	` + formatLimitAndOffset(filterFundRaiser.Limit, filterFundRaiser.Offset)
 
-	log.Debug().Str("sql query", findFundRaiserQuery).Msg("")
+	log.Ctx(ctx).Debug().Str("sql query", findFundRaiserQuery).Msg("")
 
 	rows, err := tx.Query(ctx, findFundRaiserQuery, args...)
 	if err != nil {
 		return nil, 0, err
 	}
+	defer rows.Close()
 
 	fundRaisers := make([]*frs.FundRaiser, 0)
 
 	for rows.Next() {
 		fundRaiser := frs.FundRaiser{}
-		if err := rows.Scan(&fundRaiser.ID, &fundRaiser.Title, &fundRaiser.Story, &fundRaiser.TargetAmount, &fundRaiser.CoverImg, &fundRaiser.CreatedAt, &fundRaiser.UpdatedAt); err != nil {
+		if err := rows.Scan(&fundRaiser.ID, &fundRaiser.Title, &fundRaiser.Story, &fundRaiser.TargetAmount, &fundRaiser.AmountRaised, &fundRaiser.EndsAt, &fundRaiser.CoverImg, &fundRaiser.OrganizerID, &fundRaiser.CreatedAt, &fundRaiser.UpdatedAt, &fundRaiser.Version, &fundRaiser.Snippet, &fundRaiser.Rank); err != nil {
 			return nil, 0, err
 		}
+		fundRaiser.Snippet = formatSnippet(fundRaiser.Snippet)
 		fundRaisers = append(fundRaisers, &fundRaiser)
-		if err := rows.Err(); err != nil {
-			return nil, 0, err
-		}
 	}
 
-	return fundRaisers, len(fundRaisers), nil
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return fundRaisers, n, nil
 }
 
 func findFundRaiserById(ctx context.Context, tx *Tx, id int64) (*frs.FundRaiser, error) {
@@ -205,29 +384,50 @@ func findFundRaiserById(ctx context.Context, tx *Tx, id int64) (*frs.FundRaiser,
 	return fundRaiser[0], nil
 }
 
-func deleteFundRaiser(ctx context.Context, tx *Tx, id int64) error {
-	_, err := findFundRaiserById(ctx, tx, id)
+func deleteFundRaiser(ctx context.Context, tx *Tx, id int64, version int) error {
+	fundRaiser, err := findFundRaiserById(ctx, tx, id)
 	if err != nil {
 		return err
 	}
-	deleteFundRaiserQuery := `DELETE FROM fundraisers WHERE id = $1;`
-	_, err = tx.Exec(ctx, deleteFundRaiserQuery, id)
+
+	if err := frs.AuthorizeOwner(ctx, fundRaiser.OrganizerID, frs.PermWriteFundRaisers, frs.PermManageFundRaisers); err != nil {
+		return err
+	}
+
+	if version != 0 && version != fundRaiser.Version {
+		return frs.Errorf(frs.EPRECONDITION, utils.StaleVersionMsg("fundraiser"))
+	}
+
+	// compare and swap on the version read above so a concurrent update
+	// isn't deleted unseen
+	deleteFundRaiserQuery := `DELETE FROM fundraisers WHERE id = $1 AND version = $2;`
+	tag, err := tx.Exec(ctx, deleteFundRaiserQuery, id, fundRaiser.Version)
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return frs.Errorf(frs.EPRECONDITION, utils.StaleVersionMsg("fundraiser"))
+	}
 	return nil
 }
 
+// updateFundRaiser only writes if the stored version still equals
+// fundRaiser.Version and bumps the version on success.
 func updateFundRaiser(ctx context.Context, tx *Tx, id int64, fundRaiser *frs.FundRaiser) (*frs.FundRaiser, error) {
 	updateFundRaiserQuery := `
 	 UPDATE fundraisers
-	 SET title = $1, story = $2, cover_img = $3, target_amount = $4, updated_at = $5
-	 WHERE id = $6;`
+	 SET title = $1, story = $2, cover_img = $3, target_amount = $4, ends_at = $5, updated_at = $6, version = version + 1
+	 WHERE id = $7 AND version = $8;`
 
-	_, err := tx.Exec(ctx, updateFundRaiserQuery, fundRaiser.Title, fundRaiser.Story, fundRaiser.CoverImg, fundRaiser.TargetAmount, fundRaiser.UpdatedAt, id)
+	tag, err := tx.Exec(ctx, updateFundRaiserQuery, fundRaiser.Title, fundRaiser.Story, fundRaiser.CoverImg, fundRaiser.TargetAmount, fundRaiser.EndsAt, fundRaiser.UpdatedAt, id, fundRaiser.Version)
 	if err != nil {
 		return nil, err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return nil, frs.Errorf(frs.EPRECONDITION, utils.StaleVersionMsg("fundraiser"))
+	}
+
+	fundRaiser.Version++
 	return fundRaiser, nil
 }
@@ -0,0 +1,43 @@
+package postgres_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/TezzBhandari/frs"
+	p "github.com/TezzBhandari/frs/postgres"
+)
+
+func TestFundRaiserService_FindFundRaiser_Snippet(t *testing.T) {
+	db := MustOpenDB(t)
+	s := p.NewFundRaiserService(db)
+	organizer := MustCreateUser(t, db, "organizer", frs.RoleOrganizer)
+	ctx := frs.NewContextWithUser(context.Background(), organizer)
+
+	fundRaiser := &frs.FundRaiser{
+		Title:        "Clean water <b>now</b>",
+		Story:        `Wells for the village <script>alert("water")</script>`,
+		CoverImg:     "https://example.com/well.png",
+		TargetAmount: 1000,
+	}
+	if err := s.CreateFundRaiser(ctx, fundRaiser); err != nil {
+		t.Fatal(err)
+	}
+
+	query := "water"
+	fundRaisers, _, err := s.FindFundRaiser(ctx, &frs.FilterFundRaiser{Query: &query})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(fundRaisers) != 1 {
+		t.Fatalf("got: %d results, want: 1", len(fundRaisers))
+	}
+
+	snippet := fundRaisers[0].Snippet
+	if strings.Contains(snippet, "<script>") || strings.Contains(snippet, "<b>") {
+		t.Errorf("got: %q, want markup escaped", snippet)
+	}
+	if !strings.Contains(snippet, "Clean <mark>water</mark>") {
+		t.Errorf("got: %q, want title match marked", snippet)
+	}
+}
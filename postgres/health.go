@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// Ping checks that the pool can reach the database.
+func (db *DB) Ping(ctx context.Context) error {
+	return db.db.Ping(ctx)
+}
+
+// CheckMigrations returns an error when a migration is pending or was edited
+// after it was applied. It reads without the migration lock so readiness
+// probes don't queue behind a running migration.
+func (db *DB) CheckMigrations(ctx context.Context) error {
+	migrations, applied, err := db.readMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	if err := verifyMigrations(migrations, applied); err != nil {
+		return err
+	}
+
+	var pending int
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d pending migrations", pending)
+	}
+	return nil
+}
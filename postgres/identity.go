@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *AuthService) LoginWithIdentity(ctx context.Context, identity *frs.Identity) (*frs.Auth, error) {
+	if err := identity.Validate(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	userID, err := findIdentityUserID(ctx, tx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	var registered bool
+	if userID == 0 {
+		if userID, registered, err = identityUserID(ctx, tx, identity); err != nil {
+			return nil, err
+		}
+
+		if err := createIdentity(ctx, tx, userID, identity); err != nil {
+			return nil, err
+		}
+	}
+
+	auth, err := s.newAuth(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	if registered {
+		usersRegisteredCount.WithLabelValues("identity").Inc()
+	}
+	return auth, nil
+}
+
+// findIdentityUserID returns zero when the identity is not linked yet.
+func findIdentityUserID(ctx context.Context, tx *Tx, identity *frs.Identity) (int64, error) {
+	var userID int64
+	findIdentityQuery := `SELECT user_id FROM identities WHERE provider = $1 AND subject = $2;`
+	err := tx.QueryRow(ctx, findIdentityQuery, identity.Provider, identity.Subject).Scan(&userID)
+	if err != nil && err != pgx.ErrNoRows {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// identityUserID picks the user an unlinked identity is linked to: the
+// signed in user, the passwordless user with the same email or a new one.
+// registered is set when the user was created.
+func identityUserID(ctx context.Context, tx *Tx, identity *frs.Identity) (userID int64, registered bool, err error) {
+	// the user proved they own the account by signing in to it. Not with an
+	// api key, a leaked key would hand the account to any provider account.
+	if frs.SessionFromContext(ctx) != nil {
+		return frs.UserIDFromContext(ctx), false, nil
+	}
+
+	// an unverified email could belong to anyone, linking or creating an
+	// account with it would let the provider account take it over
+	if !identity.EmailVerified {
+		return 0, false, frs.Errorf(frs.EUNAUTHORIZED, "identity provider has not verified the email")
+	}
+
+	userID, hasPassword, err := findUserByEmail(ctx, tx, identity.Email)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// signup doesn't verify emails, whoever registered the address first
+	// must not get access to the provider account's owner's data or the
+	// other way round
+	if hasPassword {
+		return 0, false, frs.Errorf(frs.ECONFLICT, "an account with this email already exists, log in with its password to link the identity")
+	}
+
+	if userID == 0 {
+		if userID, err = createIdentityUser(ctx, tx, identity); err != nil {
+			return 0, false, err
+		}
+		return userID, true, nil
+	}
+	return userID, false, nil
+}
+
+// findUserByEmail returns a zero id when no user has email. Emails match
+// regardless of case, backed by the users_email_lower_key index.
+func findUserByEmail(ctx context.Context, tx *Tx, email string) (userID int64, hasPassword bool, err error) {
+	findUserQuery := `SELECT id, password IS NOT NULL FROM users WHERE LOWER(email) = LOWER($1);`
+	err = tx.QueryRow(ctx, findUserQuery, email).Scan(&userID, &hasPassword)
+	if err != nil && err != pgx.ErrNoRows {
+		return 0, false, err
+	}
+	return userID, hasPassword, nil
+}
+
+func createIdentity(ctx context.Context, tx *Tx, userID int64, identity *frs.Identity) error {
+	insertIdentityQuery := `
+		INSERT INTO identities (id, user_id, provider, subject, email, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6);
+	`
+	id, err := tx.db.generateID()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, insertIdentityQuery, id, userID, identity.Provider, identity.Subject, identity.Email, tx.Now)
+	return err
+}
+
+var usernameReplacer = regexp.MustCompile(`[^a-zA-Z0-9_.]+`)
+
+// createIdentityUser inserts a donor without a password. The username is
+// derived from the email and suffixed with the id to keep it unique.
+func createIdentityUser(ctx context.Context, tx *Tx, identity *frs.Identity) (int64, error) {
+	id, err := tx.db.generateID()
+	if err != nil {
+		return 0, err
+	}
+
+	user := &frs.User{
+		ID:        id,
+		Email:     strings.ToLower(identity.Email),
+		Role:      frs.RoleDonor,
+		CreatedAt: tx.Now,
+		UpdatedAt: tx.Now,
+	}
+
+	suffix := "_" + strconv.FormatInt(user.ID, 36)
+	local, _, _ := strings.Cut(identity.Email, "@")
+	local = usernameReplacer.ReplaceAllString(local, "")
+	if limit := 50 - len(suffix); len(local) > limit {
+		local = local[:limit]
+	}
+	user.Username = local + suffix
+
+	insertUserQuery := `
+		INSERT INTO users (id, username, email, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6);
+	`
+	_, err = tx.Exec(ctx, insertUserQuery, user.ID, user.Username, user.Email, user.Role, user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
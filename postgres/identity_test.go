@@ -0,0 +1,93 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TezzBhandari/frs"
+	p "github.com/TezzBhandari/frs/postgres"
+)
+
+// countIdentities returns how many identities are linked to userID.
+func countIdentities(tb testing.TB, db *p.DB, userID int64) int {
+	tb.Helper()
+
+	var n int
+	if err := MustConnect(tb, db.DSN).QueryRow(context.Background(), `SELECT COUNT(*) FROM identities WHERE user_id = $1;`, userID).Scan(&n); err != nil {
+		tb.Fatal(err)
+	}
+	return n
+}
+
+func TestAuthService_LoginWithIdentity(t *testing.T) {
+	db := MustOpenDB(t)
+	s := p.NewAuthService(db, []byte("secret"))
+
+	t.Run("Register", func(t *testing.T) {
+		identity := &frs.Identity{Provider: "google", Subject: "1", Email: "New@Example.com", EmailVerified: true}
+		auth, err := s.LoginWithIdentity(context.Background(), identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the same identity logs into the same user, so does another
+		// provider's verifying the email of the passwordless user
+		if again, err := s.LoginWithIdentity(context.Background(), identity); err != nil {
+			t.Fatal(err)
+		} else if again.ID != auth.ID {
+			t.Errorf("again: got: user %d, want: %d", again.ID, auth.ID)
+		}
+		other := &frs.Identity{Provider: "github", Subject: "1", Email: "new@example.com", EmailVerified: true}
+		if linked, err := s.LoginWithIdentity(context.Background(), other); err != nil {
+			t.Fatal(err)
+		} else if linked.ID != auth.ID {
+			t.Errorf("other provider: got: user %d, want: %d", linked.ID, auth.ID)
+		}
+	})
+
+	// signup doesn't verify emails, the address may have been registered by
+	// someone else than the provider account's owner
+	t.Run("ErrPasswordAccount", func(t *testing.T) {
+		user := MustCreateUser(t, db, "jane", frs.RoleDonor)
+
+		identity := &frs.Identity{Provider: "google", Subject: "2", Email: "JANE@example.com", EmailVerified: true}
+		if _, err := s.LoginWithIdentity(context.Background(), identity); frs.ErrorCode(err) != frs.ECONFLICT {
+			t.Fatalf("got: %v, want: %q", err, frs.ECONFLICT)
+		}
+		if n := countIdentities(t, db, user.ID); n != 0 {
+			t.Fatalf("identities: got: %d, want: 0", n)
+		}
+
+		// signed in with the password the identity is linked
+		auth := MustLogin(t, context.Background(), s, user)
+		_, session, err := s.Authenticate(context.Background(), auth.AccessToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := frs.NewContextWithSession(frs.NewContextWithUser(context.Background(), user), session)
+		if linked, err := s.LoginWithIdentity(ctx, identity); err != nil {
+			t.Fatal(err)
+		} else if linked.ID != user.ID {
+			t.Errorf("signed in: got: user %d, want: %d", linked.ID, user.ID)
+		}
+
+		if again, err := s.LoginWithIdentity(context.Background(), identity); err != nil {
+			t.Fatal(err)
+		} else if again.ID != user.ID {
+			t.Errorf("linked: got: user %d, want: %d", again.ID, user.ID)
+		}
+	})
+
+	t.Run("ErrAPIKey", func(t *testing.T) {
+		user := MustCreateUser(t, db, "john", frs.RoleDonor)
+		ctx := frs.NewContextWithAPIKey(frs.NewContextWithUser(context.Background(), user), &frs.APIKey{UserID: user.ID})
+
+		identity := &frs.Identity{Provider: "google", Subject: "3", Email: user.Email, EmailVerified: true}
+		if _, err := s.LoginWithIdentity(ctx, identity); frs.ErrorCode(err) != frs.ECONFLICT {
+			t.Fatalf("got: %v, want: %q", err, frs.ECONFLICT)
+		}
+		if n := countIdentities(t, db, user.ID); n != 0 {
+			t.Errorf("identities: got: %d, want: 0", n)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ frs.LoginAttemptStore = (*LoginAttemptStore)(nil)
+
+// records untouched for this long are deleted
+const DefaultLoginAttemptTTL = 24 * time.Hour
+
+// LoginAttemptStore shares failure records between every instance using the
+// same database.
+type LoginAttemptStore struct {
+	db  *DB
+	TTL time.Duration
+}
+
+func NewLoginAttemptStore(db *DB) *LoginAttemptStore {
+	return &LoginAttemptStore{
+		db:  db,
+		TTL: DefaultLoginAttemptTTL,
+	}
+}
+
+func (s *LoginAttemptStore) FindLoginAttempts(ctx context.Context, key string) (*frs.LoginAttempts, error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	attempts := &frs.LoginAttempts{}
+	findLoginAttemptsQuery := `SELECT failures, last_failure, blocked_until FROM login_attempts WHERE key = $1;`
+	err = tx.QueryRow(ctx, findLoginAttemptsQuery, key).Scan(&attempts.Failures, &attempts.LastFailure, &attempts.BlockedUntil)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (s *LoginAttemptStore) UpdateLoginAttempts(ctx context.Context, key string, fn func(*frs.LoginAttempts)) (*frs.LoginAttempts, error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// make sure the row exists so FOR UPDATE serializes concurrent failures
+	insertLoginAttemptsQuery := `
+		INSERT INTO login_attempts (key, failures, last_failure, blocked_until)
+		VALUES ($1, 0, $2, $2)
+		ON CONFLICT (key) DO NOTHING;
+	`
+	if _, err := tx.Exec(ctx, insertLoginAttemptsQuery, key, time.Time{}); err != nil {
+		return nil, err
+	}
+
+	attempts := &frs.LoginAttempts{}
+	selectLoginAttemptsQuery := `SELECT failures, last_failure, blocked_until FROM login_attempts WHERE key = $1 FOR UPDATE;`
+	err = tx.QueryRow(ctx, selectLoginAttemptsQuery, key).Scan(&attempts.Failures, &attempts.LastFailure, &attempts.BlockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	fn(attempts)
+
+	updateLoginAttemptsQuery := `UPDATE login_attempts SET failures = $1, last_failure = $2, blocked_until = $3 WHERE key = $4;`
+	_, err = tx.Exec(ctx, updateLoginAttemptsQuery, attempts.Failures, attempts.LastFailure.UTC(), attempts.BlockedUntil.UTC(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	expired := tx.Now.Add(-s.TTL)
+	deleteExpiredQuery := `DELETE FROM login_attempts WHERE last_failure < $1 AND blocked_until < $1;`
+	if _, err := tx.Exec(ctx, deleteExpiredQuery, expired); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (s *LoginAttemptStore) DeleteLoginAttempts(ctx context.Context, key string) error {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	deleteLoginAttemptsQuery := `DELETE FROM login_attempts WHERE key = $1;`
+	if _, err := tx.Exec(ctx, deleteLoginAttemptsQuery, key); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
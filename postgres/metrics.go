@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// business counters, incremented once the creating transaction commits
+var (
+	usersRegisteredCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "frs_users_registered_total",
+		Help: "The total number of users registered.",
+	}, []string{"method"})
+
+	fundRaisersCreatedCount = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "frs_fund_raisers_created_total",
+		Help: "The total number of fund raisers created.",
+	})
+)
+
+type poolStat struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	value     func(*pgxpool.Stat) float64
+}
+
+// StatsCollector exports the connection pool statistics of a DB. It reports
+// nothing until the DB is opened.
+type StatsCollector struct {
+	db    *DB
+	stats []poolStat
+}
+
+func NewStatsCollector(db *DB) *StatsCollector {
+	stat := func(name, help string, valueType prometheus.ValueType, value func(*pgxpool.Stat) float64) poolStat {
+		return poolStat{prometheus.NewDesc("frs_db_pool_"+name, help, nil, nil), valueType, value}
+	}
+
+	return &StatsCollector{
+		db: db,
+		stats: []poolStat{
+			stat("acquired_conns", "The number of connections in use.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }),
+			stat("idle_conns", "The number of idle connections.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }),
+			stat("total_conns", "The number of open connections.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }),
+			stat("max_conns", "The maximum size of the pool.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }),
+			stat("acquires_total", "The total number of connections acquired.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }),
+			stat("empty_acquires_total", "The total number of acquires that waited for a connection.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }),
+			stat("canceled_acquires_total", "The total number of acquires canceled by their context.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) }),
+			stat("acquire_duration_seconds_total", "The total time spent acquiring connections.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }),
+		},
+	}
+}
+
+func (c *StatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, s := range c.stats {
+		ch <- s.desc
+	}
+}
+
+func (c *StatsCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.db.db == nil {
+		return
+	}
+
+	stat := c.db.db.Stat()
+	for _, s := range c.stats {
+		ch <- prometheus.MustNewConstMetric(s.desc, s.valueType, s.value(stat))
+	}
+}
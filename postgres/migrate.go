@@ -0,0 +1,464 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// migration files are named <version>_<name>.sql, e.g. 0001_create_users.sql
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+
+// markers splitting a migration file into its up and down sections
+const (
+	migrateUpMarker   = "-- +migrate up"
+	migrateDownMarker = "-- +migrate down"
+)
+
+// key of the advisory lock held while migrating so instances starting at the
+// same time don't apply the same migration twice
+const migrationLockID = 7_305_214_988
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	// empty when the migration can't be rolled back
+	Down string
+	// hex encoded SHA-256 of the whole file
+	Checksum string
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	// the file changed after it was applied
+	Modified bool
+	// applied but the file no longer exists
+	Missing bool
+}
+
+type appliedMigration struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrate applies every pending migration.
+func (db *DB) Migrate() error {
+	return db.MigrateUp(db.ctx)
+}
+
+func (db *DB) MigrateUp(ctx context.Context) error {
+	return db.withMigrationLock(ctx, func(conn *pgxpool.Conn, migrations []*Migration, applied map[int64]*appliedMigration) error {
+		if err := verifyMigrations(migrations, applied); err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if err := applyMigration(ctx, conn, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MigrateDown rolls back the last steps applied migrations.
+func (db *DB) MigrateDown(ctx context.Context, steps int) error {
+	return db.withMigrationLock(ctx, func(conn *pgxpool.Conn, migrations []*Migration, applied map[int64]*appliedMigration) error {
+		if err := verifyMigrations(migrations, applied); err != nil {
+			return err
+		}
+		_, err := rollbackMigrations(ctx, conn, migrations, applied, steps)
+		return err
+	})
+}
+
+// MigrateRedo rolls back the last applied migration and applies it again.
+func (db *DB) MigrateRedo(ctx context.Context) error {
+	return db.withMigrationLock(ctx, func(conn *pgxpool.Conn, migrations []*Migration, applied map[int64]*appliedMigration) error {
+		if err := verifyMigrations(migrations, applied); err != nil {
+			return err
+		}
+
+		rolledBack, err := rollbackMigrations(ctx, conn, migrations, applied, 1)
+		if err != nil {
+			return err
+		}
+		for _, m := range rolledBack {
+			if err := applyMigration(ctx, conn, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ErrMigrationTableMissing is returned when no migration was ever applied to
+// the database.
+var ErrMigrationTableMissing = errors.New("schema_migrations table missing, run migrate up")
+
+// MigrationStatus lists every known migration ordered by version, applied
+// ones with the time they were applied. It only reads, without the migration
+// lock, so it answers while a migration is running. When the migrations table
+// doesn't exist every migration is listed as pending together with
+// ErrMigrationTableMissing.
+func (db *DB) MigrationStatus(ctx context.Context) ([]*MigrationStatus, error) {
+	migrations, applied, err := db.readMigrations(ctx)
+	if err != nil && !errors.Is(err, ErrMigrationTableMissing) {
+		return nil, err
+	}
+
+	var statuses []*MigrationStatus
+	files := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		files[m.Version] = true
+		status := &MigrationStatus{Version: m.Version, Name: m.Name}
+		if a, ok := applied[m.Version]; ok {
+			status.AppliedAt = &a.appliedAt
+			status.Modified = a.checksum != m.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, a := range applied {
+		if !files[a.version] {
+			statuses = append(statuses, &MigrationStatus{Version: a.version, Name: a.name, AppliedAt: &a.appliedAt, Missing: true})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, err
+}
+
+// readMigrations returns the migration files and the applied migrations
+// without taking the migration lock or creating the migrations table.
+func (db *DB) readMigrations(ctx context.Context) ([]*Migration, map[int64]*appliedMigration, error) {
+	fsys, err := db.migrations()
+	if err != nil {
+		return nil, nil, err
+	}
+	migrations, err := ParseMigrations(fsys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := db.db.Acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Release()
+
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL;`).Scan(&exists); err != nil {
+		return nil, nil, err
+	}
+	if !exists {
+		return migrations, nil, ErrMigrationTableMissing
+	}
+
+	applied, err := findAppliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return migrations, applied, nil
+}
+
+// withMigrationLock runs fn on a single connection holding the migration
+// advisory lock, passing the migration files and the applied migrations.
+func (db *DB) withMigrationLock(ctx context.Context, fn func(conn *pgxpool.Conn, migrations []*Migration, applied map[int64]*appliedMigration) error) error {
+	fsys, err := db.migrations()
+	if err != nil {
+		return err
+	}
+
+	migrations, err := ParseMigrations(fsys)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("cannot acquire migration lock: %w", err)
+	}
+	defer func() {
+		// closing the session releases the lock too, the pool replaces the
+		// connection
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			log.Error().Err(err).Msg("cannot release migration lock")
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	migrationTableQuery := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`
+	if _, err := conn.Exec(ctx, migrationTableQuery); err != nil {
+		return fmt.Errorf("cannot create migrations table: %w", err)
+	}
+
+	// read after locking so another instance's migrations are seen
+	applied, err := findAppliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, migrations, applied)
+}
+
+func findAppliedMigrations(ctx context.Context, conn *pgxpool.Conn) (map[int64]*appliedMigration, error) {
+	rows, err := conn.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]*appliedMigration)
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[a.version] = &a
+	}
+	return applied, rows.Err()
+}
+
+// verifyMigrations refuses to go on when an applied migration was edited or
+// deleted, the schema would no longer match the files.
+func verifyMigrations(migrations []*Migration, applied map[int64]*appliedMigration) error {
+	files := make(map[int64]*Migration, len(migrations))
+	for _, m := range migrations {
+		files[m.Version] = m
+	}
+
+	for _, a := range applied {
+		m, ok := files[a.version]
+		if !ok {
+			return fmt.Errorf("applied migration %d_%s is missing", a.version, a.name)
+		}
+		if m.Checksum != a.checksum {
+			return fmt.Errorf("migration %d_%s was edited after it was applied, add a new migration instead", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, conn *pgxpool.Conn, m *Migration) error {
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, m.Up); err != nil {
+		return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+	}
+
+	insertMigrationQuery := `INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4);`
+	if _, err := tx.Exec(ctx, insertMigrationQuery, m.Version, m.Name, m.Checksum, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	log.Info().Msg(fmt.Sprintf("applied migration %d_%s", m.Version, m.Name))
+	return nil
+}
+
+// rollbackMigrations rolls back the last steps applied migrations, newest
+// first, and returns them in the order they were rolled back.
+func rollbackMigrations(ctx context.Context, conn *pgxpool.Conn, migrations []*Migration, applied map[int64]*appliedMigration, steps int) ([]*Migration, error) {
+	var rolledBack []*Migration
+	for i := len(migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if err := rollbackMigration(ctx, conn, m); err != nil {
+			return rolledBack, err
+		}
+		rolledBack = append(rolledBack, m)
+	}
+	return rolledBack, nil
+}
+
+func rollbackMigration(ctx context.Context, conn *pgxpool.Conn, m *Migration) error {
+	if m.Down == "" {
+		return fmt.Errorf("migration %d_%s can't be rolled back", m.Version, m.Name)
+	}
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, m.Down); err != nil {
+		return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1;`, m.Version); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	log.Info().Msg(fmt.Sprintf("rolled back migration %d_%s", m.Version, m.Name))
+	return nil
+}
+
+// migrations returns the override directory when set and the migrations
+// embedded in the binary otherwise.
+func (db *DB) migrations() (fs.FS, error) {
+	if db.MigrationsDir == "" {
+		return fs.Sub(migrationFS, "migrations")
+	}
+
+	info, err := os.Stat(db.MigrationsDir)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s path does not exist", db.MigrationsDir)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", db.MigrationsDir)
+	}
+	return os.DirFS(db.MigrationsDir), nil
+}
+
+// ParseMigrations reads every migration file in fsys ordered by version.
+func ParseMigrations(fsys fs.FS) ([]*Migration, error) {
+	names, err := readMigrationNames(fsys, ".", "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no sql files found")
+	}
+
+	migrations := make([]*Migration, 0, len(names))
+	versions := make(map[int64]string, len(names))
+	for _, name := range names {
+		buf, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := parseMigration(name, buf)
+		if err != nil {
+			return nil, err
+		}
+
+		if other, ok := versions[m.Version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, name, m.Version)
+		}
+		versions[m.Version] = name
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func parseMigration(filename string, buf []byte) (*Migration, error) {
+	match := migrationFileRegex.FindStringSubmatch(filename)
+	if match == nil {
+		return nil, fmt.Errorf("migration %s: name must look like 0001_name.sql", filename)
+	}
+
+	version, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("migration %s: %w", filename, err)
+	}
+
+	sum := sha256.Sum256(buf)
+	m := &Migration{
+		Version:  version,
+		Name:     match[2],
+		Checksum: hex.EncodeToString(sum[:]),
+	}
+
+	content := string(buf)
+	up := strings.Index(content, migrateUpMarker)
+	if up == -1 {
+		return nil, fmt.Errorf("migration %s: missing %q section", filename, migrateUpMarker)
+	}
+
+	down := strings.Index(content, migrateDownMarker)
+	switch {
+	case down == -1:
+		m.Up = content[up+len(migrateUpMarker):]
+	case down < up:
+		return nil, fmt.Errorf("migration %s: %q must come before %q", filename, migrateUpMarker, migrateDownMarker)
+	default:
+		m.Up = content[up+len(migrateUpMarker) : down]
+		m.Down = content[down+len(migrateDownMarker):]
+	}
+
+	m.Up, m.Down = strings.TrimSpace(m.Up), strings.TrimSpace(m.Down)
+	if m.Up == "" {
+		return nil, fmt.Errorf("migration %s: empty %q section", filename, migrateUpMarker)
+	}
+	return m, nil
+}
+
+// ReadMigrationDir lists the files with the given extension in a directory
+// of the embedded migrations, sorted by name.
+func ReadMigrationDir(dirName, ext string) ([]string, error) {
+	return readMigrationNames(migrationFS, dirName, ext)
+}
+
+func readMigrationNames(fsys fs.FS, dirName, ext string) ([]string, error) {
+	var files []string
+	dirEntries, err := fs.ReadDir(fsys, dirName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range dirEntries {
+		filename := entry.Name()
+		fileParts := strings.Split(filename, ".")
+		fileExtension := fileParts[len(fileParts)-1]
+		if fileExtension == ext {
+			files = append(files, filename)
+		}
+	}
+
+	return files, nil
+}
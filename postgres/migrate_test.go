@@ -0,0 +1,118 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	p "github.com/TezzBhandari/frs/postgres"
+)
+
+func TestParseMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_column.sql":   {Data: []byte("-- +migrate up\nALTER TABLE t ADD COLUMN c INT;\n-- +migrate down\nALTER TABLE t DROP COLUMN c;\n")},
+		"0001_create_table.sql": {Data: []byte("-- +migrate up\nCREATE TABLE t (id INT);\n")},
+		"README.md":             {Data: []byte("not a migration")},
+	}
+
+	migrations, err := p.ParseMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("got: %d migrations, want: 2", len(migrations))
+	}
+
+	first, second := migrations[0], migrations[1]
+	if first.Version != 1 || first.Name != "create_table" || first.Up != "CREATE TABLE t (id INT);" || first.Down != "" {
+		t.Errorf("first: got: %+v", first)
+	}
+	if second.Version != 2 || second.Up != "ALTER TABLE t ADD COLUMN c INT;" || second.Down != "ALTER TABLE t DROP COLUMN c;" {
+		t.Errorf("second: got: %+v", second)
+	}
+	if len(first.Checksum) != 64 || first.Checksum == second.Checksum {
+		t.Errorf("checksums: got: %q, %q", first.Checksum, second.Checksum)
+	}
+}
+
+func TestParseMigrations_Invalid(t *testing.T) {
+	for name, fsys := range map[string]fstest.MapFS{
+		"unnumbered":     {"create_table.sql": {Data: []byte("-- +migrate up\nSELECT 1;")}},
+		"no up section":  {"0001_create_table.sql": {Data: []byte("CREATE TABLE t (id INT);")}},
+		"empty up":       {"0001_create_table.sql": {Data: []byte("-- +migrate up\n-- +migrate down\nDROP TABLE t;")}},
+		"down before up": {"0001_create_table.sql": {Data: []byte("-- +migrate down\nDROP TABLE t;\n-- +migrate up\nSELECT 1;")}},
+		"same version": {
+			"0001_a.sql": {Data: []byte("-- +migrate up\nSELECT 1;")},
+			"1_b.sql":    {Data: []byte("-- +migrate up\nSELECT 1;")},
+		},
+		"empty": {},
+	} {
+		if _, err := p.ParseMigrations(fsys); err == nil {
+			t.Errorf("%s: got: nil error", name)
+		}
+	}
+}
+
+// every shipped migration must parse and be reversible
+func TestParseMigrations_Shipped(t *testing.T) {
+	migrations, err := p.ParseMigrations(os.DirFS("migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("%s: got: version %d, want: %d", m.Name, m.Version, i+1)
+		}
+		if m.Down == "" {
+			t.Errorf("%d_%s: missing down section", m.Version, m.Name)
+		}
+	}
+}
+
+func TestDB_MigrationStatus(t *testing.T) {
+	db := MustOpenDB(t)
+	ctx := context.Background()
+
+	statuses, err := db.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil || s.Modified || s.Missing {
+			t.Errorf("%04d_%s: got: %+v, want applied", s.Version, s.Name, s)
+		}
+	}
+
+	// the legacy bookkeeping table is dropped by a migration
+	conn := MustConnect(t, db.DSN)
+	var legacy bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('migrations') IS NOT NULL`).Scan(&legacy); err != nil {
+		t.Fatal(err)
+	} else if legacy {
+		t.Error("legacy migrations table still exists")
+	}
+
+	if _, err := conn.Exec(ctx, `DROP TABLE schema_migrations`); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err = db.MigrationStatus(ctx)
+	if !errors.Is(err, p.ErrMigrationTableMissing) {
+		t.Fatalf("got: %v, want: %v", err, p.ErrMigrationTableMissing)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt != nil {
+			t.Errorf("%04d_%s: got: applied, want pending", s.Version, s.Name)
+		}
+	}
+
+	// status only reads, it must not have recreated the table
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		t.Fatal(err)
+	} else if exists {
+		t.Error("schema_migrations created by status")
+	}
+}
@@ -0,0 +1,25 @@
+-- +migrate up
+CREATE TABLE IF NOT EXISTS fundraisers (
+    id BIGINT PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    story TEXT NOT NULL,
+    target_amount DECIMAL(10, 2) NOT NULL,
+    cover_img VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+ALTER TABLE fundraisers ADD COLUMN IF NOT EXISTS organizer_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS fundraisers_created_at_id_idx ON fundraisers (created_at DESC, id DESC);
+
+ALTER TABLE fundraisers ADD COLUMN IF NOT EXISTS amount_raised DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE fundraisers ADD COLUMN IF NOT EXISTS ends_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS fundraisers_ends_at_idx ON fundraisers (ends_at);
+
+ALTER TABLE fundraisers ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- +migrate down
+DROP TABLE IF EXISTS fundraisers;
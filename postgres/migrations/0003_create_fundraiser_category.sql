@@ -1,5 +1,9 @@
+-- +migrate up
 CREATE TABLE IF NOT EXISTS fundraiser_category (
     id BIGINT  PRIMARY KEY,
     name VARCHAR(100) NOT NULL,
     slug VARCHAR(150) NOT NULL
-);
\ No newline at end of file
+);
+
+-- +migrate down
+DROP TABLE IF EXISTS fundraiser_category;
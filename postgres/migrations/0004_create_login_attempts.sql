@@ -0,0 +1,10 @@
+-- +migrate up
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key VARCHAR(255) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure TIMESTAMP NOT NULL,
+    blocked_until TIMESTAMP NOT NULL
+);
+
+-- +migrate down
+DROP TABLE IF EXISTS login_attempts;
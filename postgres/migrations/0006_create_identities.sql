@@ -0,0 +1,13 @@
+-- +migrate up
+CREATE TABLE IF NOT EXISTS identities (
+    id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (provider, subject)
+);
+
+-- +migrate down
+DROP TABLE IF EXISTS identities;
@@ -0,0 +1,31 @@
+-- +migrate up
+ALTER TABLE fundraisers ADD COLUMN IF NOT EXISTS search tsvector;
+
+CREATE OR REPLACE FUNCTION fundraisers_search_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search :=
+        setweight(to_tsvector('english', coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(NEW.story, '')), 'B');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS fundraisers_search_trigger ON fundraisers;
+
+CREATE TRIGGER fundraisers_search_trigger
+    BEFORE INSERT OR UPDATE OF title, story ON fundraisers
+    FOR EACH ROW EXECUTE FUNCTION fundraisers_search_update();
+
+-- backfill rows created before the trigger existed
+UPDATE fundraisers SET title = title WHERE search IS NULL;
+
+CREATE INDEX IF NOT EXISTS fundraisers_search_idx ON fundraisers USING GIN (search);
+
+-- +migrate down
+DROP INDEX IF EXISTS fundraisers_search_idx;
+
+DROP TRIGGER IF EXISTS fundraisers_search_trigger ON fundraisers;
+
+DROP FUNCTION IF EXISTS fundraisers_search_update();
+
+ALTER TABLE fundraisers DROP COLUMN IF EXISTS search;
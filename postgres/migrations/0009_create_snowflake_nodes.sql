@@ -0,0 +1,9 @@
+-- +migrate up
+CREATE TABLE IF NOT EXISTS snowflake_nodes (
+    node_id INT PRIMARY KEY CHECK (node_id BETWEEN 0 AND 1023),
+    holder VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- +migrate down
+DROP TABLE IF EXISTS snowflake_nodes;
@@ -0,0 +1,11 @@
+-- +migrate up
+-- bookkeeping of the old file size based migrator, replaced by
+-- schema_migrations. Databases created by it already match 0001 to 0003, which
+-- only create missing tables.
+DROP TABLE IF EXISTS migrations;
+
+-- +migrate down
+CREATE TABLE IF NOT EXISTS migrations (
+    name VARCHAR(100) PRIMARY KEY,
+    size INTEGER NOT NULL
+);
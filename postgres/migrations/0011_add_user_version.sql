@@ -0,0 +1,5 @@
+-- +migrate up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- +migrate down
+ALTER TABLE users DROP COLUMN IF EXISTS version;
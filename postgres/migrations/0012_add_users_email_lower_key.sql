@@ -0,0 +1,8 @@
+-- +migrate up
+-- fails while addresses differing only in case exist, merge those accounts first
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (LOWER(email));
+
+UPDATE users SET email = LOWER(email) WHERE email <> LOWER(email);
+
+-- +migrate down
+DROP INDEX IF EXISTS users_email_lower_key;
@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/bwmarrin/snowflake"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Instances started by an autoscaler can't each be given a distinct NodeID.
+// With LeaseNodeID set an instance instead claims the lowest node id whose
+// lease in snowflake_nodes has expired, renews it while running and releases
+// it on Close. A crashed instance's node becomes free once its lease expires.
+
+// claimNodeQuery inserts a lease for the first free node. Two instances
+// picking the same node conflict, the loser gets no row back and retries.
+const claimNodeQuery = `
+	INSERT INTO snowflake_nodes (node_id, holder, expires_at)
+	SELECT n, $1, now() + make_interval(secs => $2)
+	FROM generate_series(0, $3::int) AS n
+	WHERE NOT EXISTS (SELECT 1 FROM snowflake_nodes s WHERE s.node_id = n AND s.expires_at > now())
+	ORDER BY n
+	LIMIT 1
+	ON CONFLICT (node_id) DO UPDATE
+	SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+	WHERE snowflake_nodes.expires_at <= now()
+	RETURNING node_id;
+`
+
+// renewNodeQuery extends our lease, taking the node back if it expired in the
+// meantime and nobody else claimed it.
+const renewNodeQuery = `
+	INSERT INTO snowflake_nodes (node_id, holder, expires_at)
+	VALUES ($1, $2, now() + make_interval(secs => $3))
+	ON CONFLICT (node_id) DO UPDATE
+	SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+	WHERE snowflake_nodes.holder = EXCLUDED.holder OR snowflake_nodes.expires_at <= now();
+`
+
+const claimNodeAttempts = 5
+
+func (db *DB) leaseNode(ctx context.Context) error {
+	holder, err := newNodeHolder()
+	if err != nil {
+		return err
+	}
+	db.nodeHolder = holder
+
+	if err := db.claimNode(ctx); err != nil {
+		return err
+	}
+
+	db.nodeLeaseDone = make(chan struct{})
+	frs.Go(db.ctx, "snowflake node lease", db.renewNodeLease)
+	return nil
+}
+
+// claimNode leases the first free node and starts generating ids from it.
+func (db *DB) claimNode(ctx context.Context) error {
+	maxNode := -1 ^ (-1 << snowflake.NodeBits)
+	for i := 0; i < claimNodeAttempts; i++ {
+		// the lease runs from before the query, the database may have
+		// started it any time after
+		claimedAt := db.Now()
+
+		var nodeID int64
+		err := db.db.QueryRow(ctx, claimNodeQuery, db.nodeHolder, db.NodeLeaseTTL.Seconds(), maxNode).Scan(&nodeID)
+		if err == pgx.ErrNoRows {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("cannot lease snowflake node: %w", err)
+		}
+
+		node, err := snowflake.NewNode(nodeID)
+		if err != nil {
+			return err
+		}
+
+		db.nodeLeaseMu.Lock()
+		db.NodeID, db.snowflake = nodeID, node
+		db.nodeLeaseUntil = claimedAt.Add(db.NodeLeaseTTL)
+		db.nodeLeaseRenewedAt, db.nodeLeaseErr = claimedAt, nil
+		db.nodeLeaseMu.Unlock()
+
+		log.Info().Int64("node", nodeID).Msg("leased snowflake node")
+		return nil
+	}
+	return fmt.Errorf("cannot lease snowflake node: all %d nodes are taken", maxNode+1)
+}
+
+func (db *DB) renewNodeLease() {
+	defer close(db.nodeLeaseDone)
+
+	ticker := time.NewTicker(db.NodeLeaseTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-db.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		renewedAt := db.Now()
+		tag, err := db.db.Exec(db.ctx, renewNodeQuery, db.NodeID, db.nodeHolder, db.NodeLeaseTTL.Seconds())
+		if db.ctx.Err() != nil {
+			return
+		}
+		switch {
+		case err != nil:
+			// ids keep coming until the lease we know of runs out
+			log.Error().Err(err).Int64("node", db.NodeID).Msg("cannot renew snowflake node lease")
+			db.setNodeLeaseStatus(time.Time{}, err)
+		case tag.RowsAffected() == 0:
+			// the lease expired and another instance claimed the node, stop
+			// generating ids that may collide with theirs and move to a free
+			// node
+			log.Error().Int64("node", db.NodeID).Msg("snowflake node lease lost to another instance")
+			db.loseNodeLease(fmt.Errorf("lease on node %d lost to another instance", db.NodeID))
+			if err := db.claimNode(db.ctx); err != nil && db.ctx.Err() == nil {
+				log.Error().Err(err).Msg("cannot lease another snowflake node")
+				db.setNodeLeaseStatus(time.Time{}, err)
+			}
+		default:
+			db.setNodeLeaseStatus(renewedAt, nil)
+		}
+	}
+}
+
+// setNodeLeaseStatus records the outcome of a renewal, a zero renewedAt
+// keeps the last successful one and the lease end that came with it.
+func (db *DB) setNodeLeaseStatus(renewedAt time.Time, err error) {
+	db.nodeLeaseMu.Lock()
+	defer db.nodeLeaseMu.Unlock()
+	if !renewedAt.IsZero() {
+		db.nodeLeaseRenewedAt = renewedAt
+		db.nodeLeaseUntil = renewedAt.Add(db.NodeLeaseTTL)
+	}
+	db.nodeLeaseErr = err
+}
+
+// loseNodeLease stops id generation until a node is leased again.
+func (db *DB) loseNodeLease(err error) {
+	db.nodeLeaseMu.Lock()
+	defer db.nodeLeaseMu.Unlock()
+	db.nodeLeaseUntil = time.Time{}
+	db.nodeLeaseErr = err
+}
+
+// generateID returns a new snowflake id. With LeaseNodeID it fails once the
+// lease ran out or was lost, so writes fail instead of risking ids another
+// instance also generates.
+func (db *DB) generateID() (int64, error) {
+	db.nodeLeaseMu.Lock()
+	node, nodeID, until := db.snowflake, db.NodeID, db.nodeLeaseUntil
+	db.nodeLeaseMu.Unlock()
+
+	if db.LeaseNodeID && !db.Now().Before(until) {
+		return 0, fmt.Errorf("snowflake node %d is not leased, no ids are issued until it is", nodeID)
+	}
+	return node.Generate().Int64(), nil
+}
+
+// Workers reports the background goroutines of the DB, the node lease
+// renewal when LeaseNodeID is set.
+func (db *DB) Workers() []frs.WorkerStatus {
+	if db.nodeLeaseDone == nil {
+		return nil
+	}
+
+	db.nodeLeaseMu.Lock()
+	defer db.nodeLeaseMu.Unlock()
+
+	renewedAt := db.nodeLeaseRenewedAt
+	status := frs.WorkerStatus{
+		Name:        "snowflake node lease",
+		LastSuccess: &renewedAt,
+		// no ids are generated without a lease
+		Healthy: db.nodeLeaseErr == nil && db.Now().Before(db.nodeLeaseUntil),
+	}
+	if db.nodeLeaseErr != nil {
+		status.Error = db.nodeLeaseErr.Error()
+	}
+	return []frs.WorkerStatus{status}
+}
+
+// releaseNode frees the node for the next instance to start. It runs after
+// the renewal goroutine has stopped.
+func (db *DB) releaseNode() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	releaseNodeQuery := `DELETE FROM snowflake_nodes WHERE node_id = $1 AND holder = $2;`
+	if _, err := db.db.Exec(ctx, releaseNodeQuery, db.NodeID, db.nodeHolder); err != nil {
+		return fmt.Errorf("cannot release snowflake node: %w", err)
+	}
+	return nil
+}
+
+// newNodeHolder identifies this process in the lease table, the hostname
+// helps find which instance holds a node.
+func newNodeHolder() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	hostname, _ := os.Hostname()
+	if len(hostname) > 47 {
+		hostname = hostname[:47]
+	}
+	return hostname + "-" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,118 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	p "github.com/TezzBhandari/frs/postgres"
+)
+
+// mustOpenLeasedDB opens another instance on the schema of base which leases
+// its node id, closed when the test ends unless the test closed it.
+func mustOpenLeasedDB(tb testing.TB, base *p.DB, ttl time.Duration) *p.DB {
+	tb.Helper()
+
+	db := p.NewDB(base.DSN)
+	db.BcryptCost = 4
+	db.SkipMigrate = true
+	db.LeaseNodeID = true
+	db.NodeLeaseTTL = ttl
+	if err := db.Open(); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.Close() })
+	return db
+}
+
+// nodeHolders returns the holder of every leased node.
+func nodeHolders(tb testing.TB, base *p.DB) map[int64]string {
+	tb.Helper()
+
+	rows, err := MustConnect(tb, base.DSN).Query(context.Background(), `SELECT node_id, holder FROM snowflake_nodes;`)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer rows.Close()
+
+	holders := make(map[int64]string)
+	for rows.Next() {
+		var nodeID int64
+		var holder string
+		if err := rows.Scan(&nodeID, &holder); err != nil {
+			tb.Fatal(err)
+		}
+		holders[nodeID] = holder
+	}
+	if err := rows.Err(); err != nil {
+		tb.Fatal(err)
+	}
+	return holders
+}
+
+func TestDB_LeaseNode(t *testing.T) {
+	base := MustOpenDB(t)
+
+	a := mustOpenLeasedDB(t, base, time.Minute)
+	b := mustOpenLeasedDB(t, base, time.Minute)
+	if a.NodeID != 0 || b.NodeID != 1 {
+		t.Fatalf("got: nodes %d and %d, want: 0 and 1", a.NodeID, b.NodeID)
+	}
+	if holders := nodeHolders(t, base); len(holders) != 2 || holders[0] == holders[1] {
+		t.Fatalf("got: %v, want: two holders", holders)
+	}
+
+	// a released node is the first free one again
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if holders := nodeHolders(t, base); len(holders) != 1 || holders[1] == "" {
+		t.Fatalf("after close: got: %v, want: node 1 only", holders)
+	}
+	if c := mustOpenLeasedDB(t, base, time.Minute); c.NodeID != 0 {
+		t.Errorf("reopen: got: node %d, want: 0", c.NodeID)
+	}
+}
+
+func TestDB_LeaseNode_Lost(t *testing.T) {
+	base := MustOpenDB(t)
+	db := mustOpenLeasedDB(t, base, 300*time.Millisecond)
+
+	// another instance takes the node over, as if our lease had expired
+	conn := MustConnect(t, base.DSN)
+	if _, err := conn.Exec(context.Background(), `UPDATE snowflake_nodes SET holder = 'thief', expires_at = now() + interval '1 hour' WHERE node_id = 0;`); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		holders := nodeHolders(t, base)
+		if len(holders) == 2 && holders[0] == "thief" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got: %v, want: another node leased", holders)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// ids come from the new node
+	MustCreateUser(t, db, "jane", frs.RoleDonor)
+}
+
+func TestDB_LeaseNode_Expired(t *testing.T) {
+	base := MustOpenDB(t)
+
+	// renewal doesn't run within the test, the lease ends an hour from now
+	db := mustOpenLeasedDB(t, base, time.Hour)
+	db.Now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	err := p.NewUserService(db).CreateUser(AdminContext(), &frs.User{Username: "jane", Email: "jane@example.com", Password: "password"})
+	if err == nil {
+		t.Fatal("got: user created, want: error while the node isn't leased")
+	}
+	if workers := db.Workers(); len(workers) != 1 || workers[0].Healthy {
+		t.Errorf("workers: got: %+v, want: unhealthy lease", workers)
+	}
+}
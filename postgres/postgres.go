@@ -3,15 +3,17 @@ package postgres
 import (
 	"context"
 	"fmt"
-	"os"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/TezzBhandari/frs"
 	"github.com/bwmarrin/snowflake"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // NullTime represents a helper wrapper for time.Time. It automatically converts
@@ -19,18 +21,50 @@ import (
 type NullTime time.Time
 
 type DB struct {
-	db        *pgxpool.Pool
-	DSN       string
-	Now       func() time.Time
-	ctx       context.Context
-	cancel    func()
-	snowflake *snowflake.Node
+	db  *pgxpool.Pool
+	DSN string
+	// optional directory read instead of the embedded migrations
+	MigrationsDir string
+	// maximum size of the connection pool, the pgx default when zero
+	MaxConns int32
+	// snowflake node id, must be unique among instances sharing a database
+	NodeID int64
+	// when set Open leases a free node id from the database instead of using
+	// NodeID, and Close releases it. Leases not renewed within NodeLeaseTTL
+	// are taken over by other instances.
+	LeaseNodeID  bool
+	NodeLeaseTTL time.Duration
+	// bcrypt cost of password hashes
+	BcryptCost int
+	// creates the BeginTx and query spans, the global provider by default
+	TracerProvider trace.TracerProvider
+	// when set Open leaves pending migrations alone, used by the migrate
+	// command which applies them itself
+	SkipMigrate bool
+	Now         func() time.Time
+	ctx         context.Context
+	cancel      func()
+	snowflake   *snowflake.Node
+
+	nodeHolder    string
+	nodeLeaseDone chan struct{}
+
+	// guards the node fields below and snowflake, which change when a lost
+	// lease is replaced
+	nodeLeaseMu        sync.Mutex
+	nodeLeaseRenewedAt time.Time
+	nodeLeaseUntil     time.Time
+	nodeLeaseErr       error
 }
 
 func NewDB(dsn string) *DB {
 	db := &DB{
-		DSN: dsn,
-		Now: time.Now,
+		DSN:            dsn,
+		Now:            time.Now,
+		NodeID:         1,
+		NodeLeaseTTL:   30 * time.Second,
+		BcryptCost:     5,
+		TracerProvider: otel.GetTracerProvider(),
 	}
 
 	db.ctx, db.cancel = context.WithCancel(context.Background())
@@ -44,175 +78,93 @@ func (db *DB) Open() error {
 		return fmt.Errorf("dsn required")
 	}
 
-	db.snowflake, err = snowflake.NewNode(1)
-
+	config, err := pgxpool.ParseConfig(db.DSN)
 	if err != nil {
 		return err
 	}
-
-	db.db, err = pgxpool.New(db.ctx, db.DSN)
-
-	if err != nil {
-		return err
+	if db.MaxConns > 0 {
+		config.MaxConns = db.MaxConns
 	}
+	config.ConnConfig.Tracer = NewQueryTracer(db.TracerProvider)
 
-	err = db.Migrate()
+	db.db, err = pgxpool.NewWithConfig(db.ctx, config)
 
-	if err != nil {
-		return fmt.Errorf("migrate: %w", err)
-	}
-	return nil
-}
-
-func (db *DB) Close() error {
-	defer db.cancel()
-	if db.db != nil {
-		db.db.Close()
-	}
-	return nil
-}
-
-func (db *DB) Migrate() error {
-	migrationTableQuery := `
-		CREATE TABLE IF NOT EXISTS migrations (
-			name VARCHAR(100) PRIMARY KEY,
-			size INTEGER NOT NULL
-		)
-	`
-	_, err := db.db.Exec(db.ctx, migrationTableQuery)
-	if err != nil {
-		return fmt.Errorf("cannot create migrations table: %w", err)
-	}
-
-	path, err := os.Getwd()
 	if err != nil {
 		return err
 	}
 
-	path += "/postgres/migrations"
-	info, err := os.Stat(path)
-
-	if os.IsNotExist(err) {
-		return fmt.Errorf("%s path does not exist", path)
+	if !db.SkipMigrate {
+		if err := db.Migrate(); err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
 	}
 
-	if !info.IsDir() {
-		return fmt.Errorf("%s is not a directory", path)
+	// leaseNode creates the node it leased
+	if db.LeaseNodeID {
+		return db.leaseNode(db.ctx)
 	}
 
-	names, err := ReadMigrationDir(path, "sql")
+	db.snowflake, err = snowflake.NewNode(db.NodeID)
 	if err != nil {
 		return err
 	}
-
-	if len(names) == 0 {
-		return fmt.Errorf("no sql files found")
-	}
-
-	for _, name := range names {
-		err := db.migrateFile(name)
-		if err != nil {
-			return fmt.Errorf("migration error: name:%q, error: %w", name, err)
-		}
-	}
-
 	return nil
 }
 
-func ReadMigrationDir(dirName, ext string) ([]string, error) {
-	var files []string
-	dirEntries, err := os.ReadDir(dirName)
-	if err != nil {
-		return nil, err
-	}
+func (db *DB) Close() error {
+	db.cancel()
 
-	for _, entry := range dirEntries {
-		filename := entry.Name()
-		fileParts := strings.Split(filename, ".")
-		fileExtension := fileParts[len(fileParts)-1]
-		if fileExtension == ext {
-			files = append(files, filename)
-		}
+	var err error
+	if db.nodeLeaseDone != nil {
+		<-db.nodeLeaseDone
+		err = db.releaseNode()
 	}
-
-	return files, nil
-}
-
-func (db *DB) migrateFile(filename string) error {
-	ctx := context.Background()
-	tx, err := db.db.BeginTx(ctx, pgx.TxOptions{})
-	defer tx.Rollback(ctx)
-	if err != nil {
-		return err
+	if db.db != nil {
+		db.db.Close()
 	}
+	return err
+}
 
-	selectMigration := `SELECT name, size, COUNT(name) AS n FROM migrations WHERE name = $1 GROUP BY name;`
-	var name string
-	var size int
-	var n int
-	err = tx.QueryRow(ctx, selectMigration, filename).Scan(&name, &size, &n)
-	if err != nil {
-		switch err {
-		case pgx.ErrNoRows:
-			log.Info().Msg(fmt.Sprintf("migration file %s is not applied", filename))
-		default:
-			return fmt.Errorf("QueryRow failed: %w", err)
-		}
+type Tx struct {
+	pgx.Tx
+	db  *DB
+	Now time.Time
+}
 
-	}
+// Exec, Query, QueryRow and Commit pass errors through FormatError so every
+// service reports constraint violations the same way.
 
-	path, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
-	path += "/postgres/migrations/" + filename
+func (tx *Tx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tag, err := tx.Tx.Exec(ctx, sql, args...)
+	return tag, FormatError(err)
+}
 
-	buf, err := os.ReadFile(path)
+func (tx *Tx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	r, err := tx.Tx.Query(ctx, sql, args...)
 	if err != nil {
-		return err
-	}
-
-	if n == 0 {
-		_, err = tx.Exec(ctx, string(buf))
-		if err != nil {
-			return err
-		}
-
-		insertMigrationQuery := `INSERT INTO migrations(name, size) VALUES($1, $2)`
-		_, err = tx.Exec(ctx, insertMigrationQuery, filename, len(buf))
-		if err != nil {
-			return err
-		}
-	} else if n != 0 && size == len(buf) {
-		log.Info().Msg(fmt.Sprintf("migration file %s already applied", name))
-		return nil // migration already applied, skip
-	} else {
-		_, err = tx.Exec(ctx, string(buf))
-		if err != nil {
-			return err
-		}
-
-		insertMigrationQuery := `UPDATE migrations SET size = $1 WHERE  name = $2;`
-		_, err = tx.Exec(ctx, insertMigrationQuery, len(buf), filename)
-		if err != nil {
-			return err
-		}
+		return nil, FormatError(err)
 	}
+	return rows{r}, nil
+}
 
-	return tx.Commit(ctx)
+func (tx *Tx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return row{tx.Tx.QueryRow(ctx, sql, args...)}
 }
 
-type Tx struct {
-	pgx.Tx
-	db  *DB
-	Now time.Time
+func (tx *Tx) Commit(ctx context.Context) error {
+	return FormatError(tx.Tx.Commit(ctx))
 }
 
 func (db *DB) BeginTx(ctx context.Context, txOpts pgx.TxOptions) (*Tx, error) {
+	// covers waiting for a pool connection, the begin statement is a child
+	ctx, span := db.TracerProvider.Tracer(tracerName).Start(ctx, "postgres.BeginTx")
+	defer span.End()
+
 	tx, err := db.db.BeginTx(ctx, txOpts)
 
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return nil, err
 	}
 
@@ -224,28 +176,39 @@ func (db *DB) BeginTx(ctx context.Context, txOpts pgx.TxOptions) (*Tx, error) {
 
 }
 
-func formatError(err error) error {
-	switch err.Error() {
-	case "ERROR: duplicate key value violates unique constraint \"users_username_key\" (SQLSTATE 23505)":
-		return frs.Errorf(frs.EBADREQUEST, "username already exists")
-	case "ERROR: duplicate key value violates unique constraint \"users_email_key\" (SQLSTATE 23505)":
-		return frs.Errorf(frs.EBADREQUEST, "email already exists")
-	default:
-		return err
+func formatLimitAndOffset(limit, offset int) string {
+	if limit <= 0 {
+		limit = frs.DefaultLimit
 	}
-}
 
-func formatLimitAndOffset(limit, offset int) string {
-	if limit > 0 && offset > 0 {
+	if offset > 0 {
 		return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
 	}
+	return fmt.Sprintf("LIMIT %d", limit)
+}
 
-	if limit > 0 {
-		return fmt.Sprintf("LIMIT %d", limit)
+// formatCursor narrows where to the rows after cursor in (created_at, id)
+// order, descending unless order is frs.SortAsc.
+func formatCursor(where string, args []any, cursor string, offset int, order string) (string, []any, error) {
+	if cursor == "" {
+		return where, args, nil
 	}
 
 	if offset > 0 {
-		return fmt.Sprintf("OFFSET %d", offset)
+		return "", nil, frs.Errorf(frs.EINVALID, "cursor and offset can't be combined")
+	}
+
+	c, err := frs.DecodeCursor(cursor)
+	if err != nil {
+		return "", nil, err
 	}
-	return "LIMIT 10"
+
+	op := "<"
+	if order == frs.SortAsc {
+		op = ">"
+	}
+
+	n := len(args)
+	where += fmt.Sprintf(" AND (created_at, id) %s ($%d, $%d)", op, n+1, n+2)
+	return where, append(args[:n:n], c.CreatedAt, c.ID), nil
 }
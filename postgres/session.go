@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ frs.SessionService = (*SessionService)(nil)
+
+// last_seen_at is only written when it is older than this, so authenticated
+// requests don't all turn into writes
+const sessionTouchInterval = time.Minute
+
+// user_agent is a VARCHAR(255), which counts characters rather than bytes
+const maxUserAgentLength = 255
+
+type SessionService struct {
+	db *DB
+}
+
+func NewSessionService(db *DB) *SessionService {
+	return &SessionService{db: db}
+}
+
+func (s *SessionService) FindSessions(ctx context.Context, userID int64) ([]*frs.Session, error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := frs.AuthorizeOwner(ctx, userID, frs.PermWriteProfile, frs.PermWriteUsers); err != nil {
+		return nil, err
+	}
+
+	sessions, err := findSessions(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current := frs.SessionFromContext(ctx); current != nil {
+		for _, session := range sessions {
+			session.Current = session.ID == current.ID
+		}
+	}
+	return sessions, nil
+}
+
+// return NOTFOUND Error
+func (s *SessionService) RevokeSession(ctx context.Context, userID, id int64) error {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := frs.AuthorizeOwner(ctx, userID, frs.PermWriteProfile, frs.PermWriteUsers); err != nil {
+		return err
+	}
+
+	if err := revokeSession(ctx, tx, userID, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *SessionService) RevokeSessions(ctx context.Context, userID int64) error {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := frs.AuthorizeOwner(ctx, userID, frs.PermWriteProfile, frs.PermWriteUsers); err != nil {
+		return err
+	}
+
+	if err := revokeSessions(ctx, tx, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func createSession(ctx context.Context, tx *Tx, session *frs.Session) error {
+	id, err := tx.db.generateID()
+	if err != nil {
+		return err
+	}
+
+	session.ID = id
+	session.CreatedAt = tx.Now
+	session.LastSeenAt = tx.Now
+	session.UserAgent = truncateUserAgent(session.UserAgent)
+
+	insertSessionQuery := `
+		INSERT INTO sessions (id, user_id, user_agent, ip, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`
+	_, err = tx.Exec(ctx, insertSessionQuery, session.ID, session.UserID, session.UserAgent, session.IP, session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+	return err
+}
+
+func findSessions(ctx context.Context, tx *Tx, userID int64) ([]*frs.Session, error) {
+	findSessionsQuery := `
+		SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC;
+	`
+	rows, err := tx.Query(ctx, findSessionsQuery, userID, tx.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]*frs.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// findActiveSession returns UNAUTHORIZED Error if the session was revoked or
+// has expired.
+func findActiveSession(ctx context.Context, tx *Tx, id int64) (*frs.Session, error) {
+	findSessionQuery := `
+		SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at
+		FROM sessions
+		WHERE id = $1 AND revoked_at IS NULL AND expires_at > $2;
+	`
+	session, err := scanSession(tx.QueryRow(ctx, findSessionQuery, id, tx.Now))
+	if err != nil {
+		switch err {
+		case pgx.ErrNoRows:
+			return nil, frs.Errorf(frs.EUNAUTHORIZED, "session expired or revoked")
+		default:
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+func touchSession(ctx context.Context, tx *Tx, session *frs.Session, client *frs.Client) error {
+	if tx.Now.Sub(session.LastSeenAt) < sessionTouchInterval && (client.IP == "" || client.IP == session.IP) {
+		return nil
+	}
+
+	session.LastSeenAt = tx.Now
+	if client.IP != "" {
+		session.IP = client.IP
+	}
+
+	touchSessionQuery := `UPDATE sessions SET last_seen_at = $1, ip = $2 WHERE id = $3;`
+	_, err := tx.Exec(ctx, touchSessionQuery, session.LastSeenAt, session.IP, session.ID)
+	return err
+}
+
+func revokeSession(ctx context.Context, tx *Tx, userID, id int64) error {
+	revokeSessionQuery := `UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL;`
+	tag, err := tx.Exec(ctx, revokeSessionQuery, tx.Now, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return frs.Errorf(frs.ENOTFOUND, utils.DoesNotExistMsg("session"))
+	}
+	return nil
+}
+
+func revokeSessions(ctx context.Context, tx *Tx, userID int64) error {
+	revokeSessionsQuery := `UPDATE sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL;`
+	_, err := tx.Exec(ctx, revokeSessionsQuery, tx.Now, userID)
+	return err
+}
+
+// truncateUserAgent cuts ua to fit the column without splitting a character,
+// invalid utf-8 from the header is dropped as postgres would reject it.
+func truncateUserAgent(ua string) string {
+	ua = strings.ToValidUTF8(ua, "")
+	if utf8.RuneCountInString(ua) <= maxUserAgentLength {
+		return ua
+	}
+	return string([]rune(ua)[:maxUserAgentLength])
+}
+
+func scanSession(row pgx.Row) (*frs.Session, error) {
+	var session frs.Session
+	if err := row.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
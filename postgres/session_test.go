@@ -0,0 +1,134 @@
+package postgres_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/TezzBhandari/frs"
+	p "github.com/TezzBhandari/frs/postgres"
+)
+
+// MustLogin logs user in with the password set by MustCreateUser.
+func MustLogin(tb testing.TB, ctx context.Context, s *p.AuthService, user *frs.User) *frs.Auth {
+	tb.Helper()
+
+	auth, err := s.Login(ctx, &frs.Credentials{Email: user.Email, Password: "password"})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return auth
+}
+
+func TestAuthService_Authenticate(t *testing.T) {
+	db := MustOpenDB(t)
+	s := p.NewAuthService(db, []byte("secret"))
+	user := MustCreateUser(t, db, "jane", frs.RoleDonor)
+	ctx := frs.NewContextWithUser(context.Background(), user)
+
+	t.Run("OK", func(t *testing.T) {
+		auth := MustLogin(t, context.Background(), s, user)
+		if got, session, err := s.Authenticate(context.Background(), auth.AccessToken); err != nil {
+			t.Fatal(err)
+		} else if got.ID != user.ID || session.ID != auth.SessionID {
+			t.Errorf("got: user %d session %d, want: user %d session %d", got.ID, session.ID, user.ID, auth.SessionID)
+		}
+	})
+
+	t.Run("ErrRevoked", func(t *testing.T) {
+		auth := MustLogin(t, context.Background(), s, user)
+		if err := p.NewSessionService(db).RevokeSession(ctx, user.ID, auth.SessionID); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := s.Authenticate(context.Background(), auth.AccessToken); frs.ErrorCode(err) != frs.EUNAUTHORIZED {
+			t.Errorf("got: %v, want: %q", err, frs.EUNAUTHORIZED)
+		}
+	})
+
+	t.Run("ErrExpired", func(t *testing.T) {
+		auth := MustLogin(t, context.Background(), s, user)
+
+		db.Now = func() time.Time { return auth.ExpiresAt.Add(time.Second) }
+		defer func() { db.Now = time.Now }()
+		if _, _, err := s.Authenticate(context.Background(), auth.AccessToken); frs.ErrorCode(err) != frs.EUNAUTHORIZED {
+			t.Errorf("got: %v, want: %q", err, frs.EUNAUTHORIZED)
+		}
+	})
+
+	t.Run("ErrPasswordChanged", func(t *testing.T) {
+		first := MustLogin(t, context.Background(), s, user)
+		second := MustLogin(t, context.Background(), s, user)
+		if err := p.NewUserService(db).SetPassword(ctx, user.ID, "password"); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, auth := range []*frs.Auth{first, second} {
+			if _, _, err := s.Authenticate(context.Background(), auth.AccessToken); frs.ErrorCode(err) != frs.EUNAUTHORIZED {
+				t.Errorf("session %d: got: %v, want: %q", auth.SessionID, err, frs.EUNAUTHORIZED)
+			}
+		}
+	})
+}
+
+// emails match regardless of case, like they do when identities are linked
+func TestAuthService_Login_EmailCase(t *testing.T) {
+	db := MustOpenDB(t)
+	s := p.NewAuthService(db, []byte("secret"))
+	user := MustCreateUser(t, db, "jane", frs.RoleDonor)
+
+	auth, err := s.Login(context.Background(), &frs.Credentials{Email: "Jane@Example.COM", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	} else if auth.ID != user.ID {
+		t.Errorf("got: user %d, want: %d", auth.ID, user.ID)
+	}
+}
+
+func TestSessionService_RevokeSession(t *testing.T) {
+	db := MustOpenDB(t)
+	auth := p.NewAuthService(db, []byte("secret"))
+	s := p.NewSessionService(db)
+	jane := MustCreateUser(t, db, "jane", frs.RoleDonor)
+	john := MustCreateUser(t, db, "john", frs.RoleDonor)
+	janeCtx := frs.NewContextWithUser(context.Background(), jane)
+
+	johns := MustLogin(t, context.Background(), auth, john)
+
+	// someone else's user id is rejected before the session is looked at
+	if err := s.RevokeSession(janeCtx, john.ID, johns.SessionID); frs.ErrorCode(err) != frs.EFORBIDDEN {
+		t.Errorf("other user: got: %v, want: %q", err, frs.EFORBIDDEN)
+	}
+
+	// and someone else's session can't be reached through your own user id
+	if err := s.RevokeSession(janeCtx, jane.ID, johns.SessionID); frs.ErrorCode(err) != frs.ENOTFOUND {
+		t.Errorf("other session: got: %v, want: %q", err, frs.ENOTFOUND)
+	}
+
+	if _, _, err := auth.Authenticate(context.Background(), johns.AccessToken); err != nil {
+		t.Errorf("session revoked by another user: %v", err)
+	}
+}
+
+func TestSessionService_FindSessions_UserAgent(t *testing.T) {
+	db := MustOpenDB(t)
+	user := MustCreateUser(t, db, "jane", frs.RoleDonor)
+
+	ua := strings.Repeat("é", 300)
+	ctx := frs.NewContextWithClient(context.Background(), &frs.Client{UserAgent: ua})
+	MustLogin(t, ctx, p.NewAuthService(db, []byte("secret")), user)
+
+	sessions, err := p.NewSessionService(db).FindSessions(frs.NewContextWithUser(context.Background(), user), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(sessions) != 1 {
+		t.Fatalf("got: %d sessions, want: 1", len(sessions))
+	}
+
+	got := sessions[0].UserAgent
+	if !utf8.ValidString(got) || got != strings.Repeat("é", 255) {
+		t.Errorf("got: %d characters, want: 255 whole characters", utf8.RuneCountInString(got))
+	}
+}
@@ -0,0 +1,46 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	p "github.com/TezzBhandari/frs/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := p.NewQueryTracer(provider)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	for _, sql := range []string{"begin", "SELECT id FROM users WHERE id = $1;", "commit"} {
+		queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: sql})
+		tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+	}
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "  insert into users"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{Err: errors.New("boom")})
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 5 {
+		t.Fatalf("got: %d spans, want: 5", len(spans))
+	}
+	for i, want := range []string{"BEGIN", "SELECT", "COMMIT", "INSERT"} {
+		span := spans[i]
+		if span.Name() != want {
+			t.Errorf("%d: name: got: %q, want: %q", i, span.Name(), want)
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s: not a child of the request span", want)
+		}
+	}
+	if got := spans[3].Status().Code; got != codes.Error {
+		t.Errorf("failed query status: got: %s, want: %s", got, codes.Error)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/utils"
 	"github.com/jackc/pgx/v5"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -29,6 +30,10 @@ func (s *UserService) DeleteUser(ctx context.Context, id int64) error {
 	}
 	defer tx.Rollback(ctx)
 
+	if err := frs.AuthorizeOwner(ctx, id, frs.PermWriteProfile, frs.PermWriteUsers); err != nil {
+		return err
+	}
+
 	err = deleteUser(ctx, tx, id)
 
 	if err != nil {
@@ -45,11 +50,21 @@ func (s *UserService) CreateUser(ctx context.Context, user *frs.User) error {
 		return err
 	}
 	defer tx.Rollback(ctx)
+
+	// only admins may pick a role on creation, everyone else signs up as a donor
+	if user.Role == "" || frs.Authorize(ctx, frs.PermAssignRoles) != nil {
+		user.Role = frs.RoleDonor
+	}
+
 	err = createUser(ctx, tx, user)
 	if err != nil {
 		return err
 	}
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	usersRegisteredCount.WithLabelValues("password").Inc()
+	return nil
 }
 
 // return NOTFOUND Error | UNAUTHORIZED Error
@@ -73,6 +88,10 @@ func (s *UserService) UpdateUser(ctx context.Context, id int64, updUser frs.Upda
 	}
 	defer tx.Rollback(ctx)
 
+	if err := frs.AuthorizeOwner(ctx, id, frs.PermWriteProfile, frs.PermWriteUsers); err != nil {
+		return nil, err
+	}
+
 	user, err := updateUser(ctx, tx, id, updUser)
 	if err != nil {
 		return nil, err
@@ -86,19 +105,48 @@ func (s *UserService) UpdateUser(ctx context.Context, id int64, updUser frs.Upda
 }
 
 // return NOTFOUND | UNAUTHORIZED Error
+func (s *UserService) ReplaceUser(ctx context.Context, id int64, user *frs.User) (*frs.User, error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// don't tell a caller who may not write the user what is wrong with it
+	if err := frs.AuthorizeOwner(ctx, id, frs.PermWriteProfile, frs.PermWriteUsers); err != nil {
+		return nil, err
+	}
+
+	if err := user.ValidateProfile(); err != nil {
+		return nil, err
+	}
+
+	upd := frs.UpdateUser{Username: &user.Username, Email: &user.Email}
+	if user.Version != 0 {
+		upd.Version = &user.Version
+	}
+	user, err = updateUser(ctx, tx, id, upd)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func (s *UserService) FindUsers(ctx context.Context, filterUser *frs.FilterUser) ([]*frs.User, int, error) {
 	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return nil, 0, err
 	}
 	defer tx.Rollback(ctx)
-	// apiKey := make([]byte, 32)
-	// _, err = io.ReadFull(rand.Reader, apiKey)
-	// if err != nil {
-	// 	return nil, 0, err
-	// }
 
-	// fmt.Println(hex.EncodeToString(apiKey))
+	if err := frs.Authorize(ctx, frs.PermReadUsers); err != nil {
+		return nil, 0, err
+	}
 
 	users, n, err := findUsers(ctx, tx, filterUser)
 	if err != nil {
@@ -107,42 +155,125 @@ func (s *UserService) FindUsers(ctx context.Context, filterUser *frs.FilterUser)
 	return users, n, nil
 }
 
+// return NOTFOUND | UNAUTHORIZED | FORBIDDEN Error
+func (s *UserService) AssignRole(ctx context.Context, id int64, role frs.Role) (*frs.User, error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := frs.Authorize(ctx, frs.PermAssignRoles); err != nil {
+		return nil, err
+	}
+
+	user, err := assignRole(ctx, tx, id, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func createUser(ctx context.Context, tx *Tx, user *frs.User) error {
 	err := user.Validate()
 	if err != nil {
 		return err
 	}
 
-	// password should be no more than 72 bytes
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(user.Password), 5)
+	if !user.Role.Valid() {
+		return frs.Errorf(frs.EBADREQUEST, "invalid role")
+	}
+
+	passwordHash, err := hashPassword(user.Password, tx.db.BcryptCost)
 	if err != nil {
 		return err
 	}
 
+	// emails are unique regardless of case, see users_email_lower_key
+	user.Email = strings.ToLower(user.Email)
 	user.CreatedAt = tx.Now
 	user.UpdatedAt = user.CreatedAt
-	user.ID = int64(tx.db.snowflake.Generate().Int64())
+	user.Version = 1
+	user.ID, err = tx.db.generateID()
+	if err != nil {
+		return err
+	}
 	insertUserQuery := `
 		INSERT INTO users (
 			id,
 			username,
 			email,
 			password,
+			role,
 			created_at,
 			updated_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6);
+		VALUES ($1, $2, $3, $4, $5, $6, $7);
 	`
 
-	_, err = tx.Exec(ctx, insertUserQuery, user.ID, user.Username, user.Email, passwordHash, user.CreatedAt, user.UpdatedAt)
+	_, err = tx.Exec(ctx, insertUserQuery, user.ID, user.Username, user.Email, passwordHash, user.Role, user.CreatedAt, user.UpdatedAt)
+	return err
+}
+
+func (s *UserService) SetPassword(ctx context.Context, id int64, password string) error {
+	if err := frs.ValidatePassword(password); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := frs.AuthorizeOwner(ctx, id, frs.PermWriteProfile, frs.PermWriteUsers); err != nil {
+		return err
+	}
+
+	if err := setPassword(ctx, tx, id, password); err != nil {
+		return err
+	}
+
+	// whoever knew the old password must not stay logged in
+	if err := revokeSessions(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func hashPassword(password string, cost int) ([]byte, error) {
+	// password should be no more than 72 bytes
+	return bcrypt.GenerateFromPassword([]byte(password), cost)
+}
+
+func setPassword(ctx context.Context, tx *Tx, id int64, password string) error {
+	passwordHash, err := hashPassword(password, tx.db.BcryptCost)
+	if err != nil {
+		return err
+	}
+
+	setPasswordQuery := `UPDATE users SET password = $1, updated_at = $2 WHERE id = $3;`
+	tag, err := tx.Exec(ctx, setPasswordQuery, passwordHash, tx.Now, id)
 	if err != nil {
-		return formatError(err)
+		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return frs.Errorf(frs.ENOTFOUND, "user does not exist")
+	}
 	return nil
 }
 
 func findUsers(ctx context.Context, tx *Tx, filterUser *frs.FilterUser) ([]*frs.User, int, error) {
+	if err := filterUser.Validate(); err != nil {
+		return nil, 0, err
+	}
+
 	where := []string{"1 = 1"}
 	args := []any{}
 	var i int = 1
@@ -154,7 +285,7 @@ func findUsers(ctx context.Context, tx *Tx, filterUser *frs.FilterUser) ([]*frs.
 	}
 
 	if filterUser.Email != nil {
-		where = append(where, fmt.Sprintf("email = $%d", i))
+		where = append(where, fmt.Sprintf("LOWER(email) = LOWER($%d)", i))
 		args = append(args, *filterUser.Email)
 		i++
 	}
@@ -166,11 +297,24 @@ func findUsers(ctx context.Context, tx *Tx, filterUser *frs.FilterUser) ([]*frs.
 	}
 
 	whereClause := strings.Join(where, " AND ")
+
+	// total number of matches, ignoring limit and offset
+	var n int
+	countUserQuery := `SELECT COUNT(*) FROM users WHERE ` + whereClause
+	if err := tx.QueryRow(ctx, countUserQuery, args...).Scan(&n); err != nil {
+		return nil, 0, err
+	}
+
+	whereClause, args, err := formatCursor(whereClause, args, filterUser.Cursor, filterUser.Offset, frs.SortDesc)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	findUserQuery := `
 	SELECT 
-	id, username, email, created_at, updated_at
+	id, username, email, role, created_at, updated_at, version
 	FROM users WHERE ` + whereClause +
-		` ORDER BY created_at DESC
+		` ORDER BY created_at DESC, id DESC
 	` +
 		formatLimitAndOffset(filterUser.Limit, filterUser.Offset)
 
@@ -185,7 +329,7 @@ func findUsers(ctx context.Context, tx *Tx, filterUser *frs.FilterUser) ([]*frs.
 
 	for rows.Next() {
 		var user frs.User
-		if err = rows.Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		if err = rows.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.Version); err != nil {
 			return nil, 0, err
 		}
 		users = append(users, &user)
@@ -194,7 +338,7 @@ func findUsers(ctx context.Context, tx *Tx, filterUser *frs.FilterUser) ([]*frs.
 	if err = rows.Err(); err != nil {
 		return nil, 0, err
 	}
-	return users, len(users), nil
+	return users, n, nil
 }
 
 func findUserById(ctx context.Context, tx *Tx, id int64) (*frs.User, error) {
@@ -231,8 +375,12 @@ func updateUser(ctx context.Context, tx *Tx, id int64, updateUser frs.UpdateUser
 		return nil, err
 	}
 
+	if v := updateUser.Version; v != nil && *v != user.Version {
+		return nil, frs.Errorf(frs.EPRECONDITION, utils.StaleVersionMsg("user"))
+	}
+
 	if v := updateUser.Email; v != nil {
-		user.Email = *v
+		user.Email = strings.ToLower(*v)
 	}
 
 	if v := updateUser.Username; v != nil {
@@ -241,16 +389,43 @@ func updateUser(ctx context.Context, tx *Tx, id int64, updateUser frs.UpdateUser
 
 	user.UpdatedAt = tx.Now
 
-	// TODO: user can only edit only it info. right now any one can edit based on user id
-
+	// the version check also catches a write committed since the read above
 	updateUserQuery := `
 	UPDATE users
-	SET username = $1, email = $2, updated_at = $3
-	WHERE id = $4;
+	SET username = $1, email = $2, updated_at = $3, version = version + 1
+	WHERE id = $4 AND version = $5;
 	`
-	_, err = tx.Exec(ctx, updateUserQuery, user.Username, user.Email, user.UpdatedAt, id)
+	tag, err := tx.Exec(ctx, updateUserQuery, user.Username, user.Email, user.UpdatedAt, id, user.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return nil, frs.Errorf(frs.EPRECONDITION, utils.StaleVersionMsg("user"))
+	}
+
+	user.Version++
+	return user, nil
+}
+
+func assignRole(ctx context.Context, tx *Tx, id int64, role frs.Role) (*frs.User, error) {
+	if !role.Valid() {
+		return nil, frs.Errorf(frs.EBADREQUEST, "invalid role")
+	}
+
+	user, err := findUserById(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	user.UpdatedAt = tx.Now
+
+	assignRoleQuery := `UPDATE users SET role = $1, updated_at = $2, version = version + 1 WHERE id = $3;`
+	_, err = tx.Exec(ctx, assignRoleQuery, user.Role, user.UpdatedAt, id)
 	if err != nil {
 		return nil, err
 	}
+	user.Version++
 	return user, nil
 }
@@ -0,0 +1,56 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TezzBhandari/frs"
+	p "github.com/TezzBhandari/frs/postgres"
+)
+
+func TestUserService_ReplaceUser(t *testing.T) {
+	db := MustOpenDB(t)
+	s := p.NewUserService(db)
+	jane := MustCreateUser(t, db, "jane", frs.RoleDonor)
+	john := MustCreateUser(t, db, "john", frs.RoleDonor)
+	ctx := frs.NewContextWithUser(context.Background(), jane)
+
+	t.Run("ErrForbiddenBeforeInvalid", func(t *testing.T) {
+		_, err := s.ReplaceUser(frs.NewContextWithUser(context.Background(), john), jane.ID, &frs.User{})
+		if frs.ErrorCode(err) != frs.EFORBIDDEN {
+			t.Errorf("got: %v, want: %q", err, frs.EFORBIDDEN)
+		}
+	})
+
+	t.Run("Version", func(t *testing.T) {
+		user, err := s.ReplaceUser(ctx, jane.ID, &frs.User{Username: "janet", Email: jane.Email, Version: jane.Version})
+		if err != nil {
+			t.Fatal(err)
+		} else if user.Version != jane.Version+1 {
+			t.Errorf("got: version %d, want: %d", user.Version, jane.Version+1)
+		}
+
+		// the write above went in since jane.Version was read
+		_, err = s.ReplaceUser(ctx, jane.ID, &frs.User{Username: "jane", Email: jane.Email, Version: jane.Version})
+		if frs.ErrorCode(err) != frs.EPRECONDITION {
+			t.Errorf("stale: got: %v, want: %q", err, frs.EPRECONDITION)
+		}
+	})
+}
+
+func TestFundRaiserService_ReplaceFundRaiser_ErrForbiddenBeforeInvalid(t *testing.T) {
+	db := MustOpenDB(t)
+	s := p.NewFundRaiserService(db)
+	owner := MustCreateUser(t, db, "owner", frs.RoleOrganizer)
+	other := MustCreateUser(t, db, "other", frs.RoleOrganizer)
+
+	fundRaiser := &frs.FundRaiser{Title: "Clean water", Story: "Wells", CoverImg: "well.png", TargetAmount: 500}
+	if err := s.CreateFundRaiser(frs.NewContextWithUser(context.Background(), owner), fundRaiser); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := s.ReplaceFundRaiser(frs.NewContextWithUser(context.Background(), other), fundRaiser.ID, &frs.FundRaiser{})
+	if frs.ErrorCode(err) != frs.EFORBIDDEN {
+		t.Errorf("got: %v, want: %q", err, frs.EFORBIDDEN)
+	}
+}
@@ -0,0 +1,168 @@
+package frs
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Report describes a panic or an unexpected error for an error tracker.
+type Report struct {
+	Time time.Time `json:"time"`
+	// fatal for panics, error otherwise
+	Level   string `json:"level"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	// innermost frame first
+	Stack []StackFrame `json:"stack"`
+
+	RequestID string         `json:"request_id,omitempty"`
+	UserID    int64          `json:"user_id,omitempty"`
+	Request   *ReportRequest `json:"request,omitempty"`
+	// e.g. the name of the background goroutine that panicked
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// ReportRequest is the part of the http request worth reporting, headers
+// are left out as they carry credentials.
+type ReportRequest struct {
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// StackTrace formats the stack like a goroutine dump, for logs.
+func (r *Report) StackTrace() string {
+	var b strings.Builder
+	for _, frame := range r.Stack {
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+	return b.String()
+}
+
+// PanicReporter sends reports to an error tracker.
+type PanicReporter interface {
+	Report(ctx context.Context, report *Report) error
+}
+
+// DefaultPanicReporter receives every report, they are dropped while it is
+// nil. It is set once at startup.
+var DefaultPanicReporter PanicReporter
+
+// SendReport passes report to the DefaultPanicReporter.
+func SendReport(ctx context.Context, report *Report) error {
+	if DefaultPanicReporter == nil {
+		return nil
+	}
+	return DefaultPanicReporter.Report(ctx, report)
+}
+
+// FlushReports waits for the reports the DefaultPanicReporter holds on to,
+// if it queues them, to be sent. Called before the process crashes.
+func FlushReports(ctx context.Context) error {
+	flusher, ok := DefaultPanicReporter.(interface {
+		Flush(ctx context.Context) error
+	})
+	if !ok {
+		return nil
+	}
+	return flusher.Flush(ctx)
+}
+
+// NewPanicReport describes a recovered panic. It must be called from the
+// deferred function that recovered so the stack still leads to the panic.
+func NewPanicReport(ctx context.Context, value any) *Report {
+	report := newReport(ctx, "fatal", value)
+	report.Stack = panicStack()
+	return report
+}
+
+// NewErrorReport describes an error that should not have happened, with the
+// stack of the caller.
+func NewErrorReport(ctx context.Context, err error) *Report {
+	report := newReport(ctx, "error", err)
+	report.Stack = callerStack(2)
+	return report
+}
+
+func newReport(ctx context.Context, level string, value any) *Report {
+	report := &Report{
+		Time:      time.Now().UTC(),
+		Level:     level,
+		Type:      fmt.Sprintf("%T", value),
+		Message:   fmt.Sprint(value),
+		RequestID: RequestIDFromContext(ctx),
+		UserID:    UserIDFromContext(ctx),
+	}
+	return report
+}
+
+// ReportPanic reports a recovered panic value, see NewPanicReport.
+func ReportPanic(ctx context.Context, value any) error {
+	return SendReport(ctx, NewPanicReport(ctx, value))
+}
+
+// Go runs fn in a goroutine, reporting a panic before letting it crash the
+// process like it would have without the report.
+func Go(ctx context.Context, name string, fn func()) {
+	go func() {
+		defer func() {
+			if value := recover(); value != nil {
+				report := NewPanicReport(ctx, value)
+				report.Tags = map[string]string{"goroutine": name}
+				SendReport(ctx, report)
+
+				// the report may be queued, it must go out before the crash
+				flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+				FlushReports(flushCtx)
+				cancel()
+				panic(value)
+			}
+		}()
+		fn()
+	}()
+}
+
+// panicStack returns the frames from the panic site down, dropping the
+// recovering function and the runtime's panic machinery, including the
+// signal handling frames of a nil dereference.
+func panicStack() []StackFrame {
+	stack := callerStack(0)
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].Function == "runtime.gopanic" {
+			stack = stack[i+1:]
+			break
+		}
+	}
+	for len(stack) > 1 && strings.HasPrefix(stack[0].Function, "runtime.") {
+		stack = stack[1:]
+	}
+	return stack
+}
+
+func callerStack(skip int) []StackFrame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pcs)
+
+	var stack []StackFrame
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.goexit") {
+			stack = append(stack, StackFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
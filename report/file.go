@@ -0,0 +1,44 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/TezzBhandari/frs"
+)
+
+// FileReporter appends reports to a file as json lines, for deployments
+// without an error tracker.
+type FileReporter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func OpenFileReporter(path string) (*FileReporter, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open report file: %w", err)
+	}
+	return &FileReporter{file: file}, nil
+}
+
+func (r *FileReporter) Report(ctx context.Context, report *frs.Report) error {
+	buf, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.file.Write(append(buf, '\n')); err != nil {
+		return fmt.Errorf("cannot write report: %w", err)
+	}
+	return nil
+}
+
+func (r *FileReporter) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,47 @@
+package report_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/report"
+)
+
+func TestFileReporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports.jsonl")
+	reporter, err := report.OpenFileReporter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := reporter.Report(context.Background(), newReport()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := reporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(buf), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("got: %d lines, want: 2", len(lines))
+	}
+
+	var got frs.Report
+	if err := json.Unmarshal(lines[1], &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := newReport(); !reflect.DeepEqual(&got, want) {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultQueueSize is enough to ride out a burst of failing requests, reports
+// beyond it are dropped rather than held in memory during an outage.
+const DefaultQueueSize = 256
+
+// ErrQueueFull is returned by Queue.Report when the report was dropped.
+var ErrQueueFull = errors.New("report queue full")
+
+// Queue sends reports to a reporter from a single goroutine so callers, most
+// of all request handlers, never wait on the error tracker.
+type Queue struct {
+	reporter frs.PanicReporter
+	items    chan queueItem
+	done     chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// flush is set on the marker Flush sends through the queue instead of a report
+type queueItem struct {
+	ctx    context.Context
+	report *frs.Report
+	flush  chan struct{}
+}
+
+// NewQueue starts a queue holding up to size reports for reporter.
+func NewQueue(reporter frs.PanicReporter, size int) *Queue {
+	q := &Queue{
+		reporter: reporter,
+		items:    make(chan queueItem, size),
+		done:     make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Report queues report and returns ErrQueueFull instead of blocking.
+func (q *Queue) Report(ctx context.Context, report *frs.Report) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueFull
+	}
+
+	// the request is usually over by the time the report goes out
+	select {
+	case q.items <- queueItem{ctx: context.WithoutCancel(ctx), report: report}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Flush waits until the reports queued before it was called have been sent.
+func (q *Queue) Flush(ctx context.Context) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return nil
+	}
+
+	flushed := make(chan struct{})
+	select {
+	case q.items <- queueItem{flush: flushed}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close sends the queued reports, giving up when ctx is done, and stops the
+// queue. Later reports are dropped.
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	close(q.items)
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+	for item := range q.items {
+		if item.flush != nil {
+			close(item.flush)
+			continue
+		}
+		if err := q.reporter.Report(item.ctx, item.report); err != nil {
+			log.Error().Err(err).Msg("cannot send report")
+		}
+	}
+}
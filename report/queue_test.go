@@ -0,0 +1,77 @@
+package report_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/report"
+)
+
+// blockingReporter holds every report until release is closed.
+type blockingReporter struct {
+	release chan struct{}
+
+	mu      sync.Mutex
+	reports []*frs.Report
+}
+
+func (r *blockingReporter) Report(ctx context.Context, report *frs.Report) error {
+	<-r.release
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	reporter := &blockingReporter{release: make(chan struct{})}
+	q := report.NewQueue(reporter, 2)
+
+	// one report is taken by the sender, two wait in the queue
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 3; i++ {
+			if err := q.Report(context.Background(), newReport()); err != nil {
+				t.Errorf("report %d: %v", i, err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Report blocked on the reporter")
+	}
+
+	if err := q.Report(context.Background(), newReport()); !errors.Is(err, report.ErrQueueFull) {
+		t.Errorf("full: got: %v, want: %v", err, report.ErrQueueFull)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := q.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("flush while blocked: got: %v", err)
+	}
+
+	close(reporter.release)
+	if err := q.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	reporter.mu.Lock()
+	if len(reporter.reports) != 3 {
+		t.Errorf("got: %d reports sent, want: 3", len(reporter.reports))
+	}
+	reporter.mu.Unlock()
+
+	if err := q.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Report(context.Background(), newReport()); !errors.Is(err, report.ErrQueueFull) {
+		t.Errorf("closed: got: %v, want: %v", err, report.ErrQueueFull)
+	}
+}
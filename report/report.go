@@ -0,0 +1,24 @@
+// Package report implements frs.PanicReporter: a client sending Sentry
+// envelopes, which any Sentry compatible tracker accepts, and a file sink
+// writing one json report per line.
+package report
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TezzBhandari/frs"
+)
+
+// Multi sends every report to each reporter.
+type Multi []frs.PanicReporter
+
+func (m Multi) Report(ctx context.Context, report *frs.Report) error {
+	var errs []error
+	for _, r := range m {
+		if err := r.Report(ctx, report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
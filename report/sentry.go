@@ -0,0 +1,201 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+)
+
+// frames of these packages are marked as in app, the tracker folds the rest
+const modulePrefix = "github.com/TezzBhandari/frs"
+
+// SentryReporter posts reports as events in a Sentry envelope.
+type SentryReporter struct {
+	dsn       string
+	publicKey string
+	endpoint  string
+
+	// optional, shown on every event
+	Environment string
+	Release     string
+	ServerName  string
+
+	HTTPClient *http.Client
+}
+
+// NewSentryReporter parses a dsn of the form
+// https://<public key>@<host>[/<path>]/<project id>.
+func NewSentryReporter(dsn string) (*SentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid sentry dsn")
+	}
+
+	projectID := path.Base(u.Path)
+	if _, err := strconv.ParseUint(projectID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid sentry dsn: project id must be a number")
+	}
+	prefix := strings.TrimSuffix(path.Dir(u.Path), "/")
+
+	endpoint := url.URL{Scheme: u.Scheme, Host: u.Host, Path: prefix + "/api/" + projectID + "/envelope/"}
+	return &SentryReporter{
+		dsn:        dsn,
+		publicKey:  u.User.Username(),
+		endpoint:   endpoint.String(),
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+func (r *SentryReporter) Report(ctx context.Context, report *frs.Report) error {
+	eventID, err := newEventID()
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(r.newEvent(eventID, report))
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	enc.Encode(map[string]any{"event_id": eventID, "sent_at": time.Now().UTC().Format(time.RFC3339), "dsn": r.dsn})
+	enc.Encode(map[string]any{"type": "event", "length": len(payload)})
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	// a panic often comes with a canceled request, the report should still go
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodPost, r.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", "Sentry sentry_version=7, sentry_client=frs/1.0, sentry_key="+r.publicKey)
+
+	resp, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot send report: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cannot send report: %s", resp.Status)
+	}
+	return nil
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Exception   sentryExceptions  `json:"exception"`
+	Request     *sentryRequest    `json:"request,omitempty"`
+	User        *sentryUser       `json:"user,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string           `json:"type"`
+	Value      string           `json:"value"`
+	Stacktrace sentryStacktrace `json:"stacktrace"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type sentryRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+type sentryUser struct {
+	ID        string `json:"id,omitempty"`
+	IPAddress string `json:"ip_address,omitempty"`
+}
+
+func (r *SentryReporter) newEvent(eventID string, report *frs.Report) *sentryEvent {
+	event := &sentryEvent{
+		EventID:     eventID,
+		Timestamp:   report.Time,
+		Platform:    "go",
+		Level:       report.Level,
+		Environment: r.Environment,
+		Release:     r.Release,
+		ServerName:  r.ServerName,
+		Tags:        make(map[string]string),
+	}
+
+	// sentry wants the outermost frame first
+	frames := make([]sentryFrame, 0, len(report.Stack))
+	for i := len(report.Stack) - 1; i >= 0; i-- {
+		frame := report.Stack[i]
+		frames = append(frames, sentryFrame{
+			Function: frame.Function,
+			AbsPath:  frame.File,
+			Lineno:   frame.Line,
+			InApp:    strings.HasPrefix(frame.Function, modulePrefix),
+		})
+	}
+	event.Exception.Values = []sentryException{{Type: report.Type, Value: report.Message, Stacktrace: sentryStacktrace{Frames: frames}}}
+
+	if report.UserID != 0 {
+		event.User = &sentryUser{ID: strconv.FormatInt(report.UserID, 10)}
+	}
+	if req := report.Request; req != nil {
+		event.Request = &sentryRequest{
+			Method:  req.Method,
+			URL:     req.URL,
+			Headers: map[string]string{"User-Agent": req.UserAgent},
+			Env:     map[string]string{"REMOTE_ADDR": req.IP},
+		}
+	}
+	for k, v := range report.Tags {
+		event.Tags[k] = v
+	}
+	if report.RequestID != "" {
+		event.Tags["request_id"] = report.RequestID
+	}
+	return event
+}
+
+// newEventID returns a uuid4 without dashes, as sentry expects.
+func newEventID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	buf[6] = buf[6]&0x0f | 0x40
+	buf[8] = buf[8]&0x3f | 0x80
+	return hex.EncodeToString(buf), nil
+}
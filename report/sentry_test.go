@@ -0,0 +1,129 @@
+package report_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/report"
+)
+
+func newReport() *frs.Report {
+	return &frs.Report{
+		Time:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Level:   "fatal",
+		Type:    "runtime.Error",
+		Message: "nil pointer dereference",
+		Stack: []frs.StackFrame{
+			{Function: "github.com/TezzBhandari/frs/http.(*Server).handleFindUsers", File: "/src/http/user.go", Line: 60},
+			{Function: "net/http.HandlerFunc.ServeHTTP", File: "/go/src/net/http/server.go", Line: 2166},
+		},
+		RequestID: "req-1",
+		UserID:    7,
+		Request:   &frs.ReportRequest{Method: "GET", URL: "/api/v1/users", IP: "10.0.0.1", UserAgent: "curl"},
+	}
+}
+
+func TestSentryReporter(t *testing.T) {
+	var lines []map[string]any
+	var auth, path string
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		auth, path = r.Header.Get("X-Sentry-Auth"), r.URL.Path
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var line map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Errorf("%s: %v", scanner.Text(), err)
+			}
+			lines = append(lines, line)
+		}
+	}))
+	defer ts.Close()
+
+	dsn := strings.Replace(ts.URL, "http://", "http://public@", 1) + "/sentry/42"
+	reporter, err := report.NewSentryReporter(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter.Environment = "test"
+	if err := reporter.Report(context.Background(), newReport()); err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "/sentry/api/42/envelope/" {
+		t.Errorf("path: got: %q", path)
+	}
+	if !strings.Contains(auth, "sentry_key=public") {
+		t.Errorf("auth: got: %q", auth)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("got: %d envelope lines, want: 3", len(lines))
+	}
+	if lines[0]["event_id"] != lines[2]["event_id"] || lines[1]["type"] != "event" {
+		t.Errorf("headers: got: %v %v", lines[0], lines[1])
+	}
+
+	var event struct {
+		Level       string            `json:"level"`
+		Environment string            `json:"environment"`
+		User        map[string]string `json:"user"`
+		Tags        map[string]string `json:"tags"`
+		Request     map[string]any    `json:"request"`
+		Exception   struct {
+			Values []struct {
+				Value      string `json:"value"`
+				Stacktrace struct {
+					Frames []struct {
+						Function string `json:"function"`
+						InApp    bool   `json:"in_app"`
+					} `json:"frames"`
+				} `json:"stacktrace"`
+			} `json:"values"`
+		} `json:"exception"`
+	}
+	buf, _ := json.Marshal(lines[2])
+	if err := json.Unmarshal(buf, &event); err != nil {
+		t.Fatal(err)
+	}
+
+	if event.Level != "fatal" || event.Environment != "test" || event.User["id"] != "7" || event.Tags["request_id"] != "req-1" {
+		t.Errorf("event: got: %+v", event)
+	}
+	if event.Request["method"] != "GET" || event.Request["url"] != "/api/v1/users" {
+		t.Errorf("request: got: %v", event.Request)
+	}
+	frames := event.Exception.Values[0].Stacktrace.Frames
+	// outermost first, only our own frames in app
+	if len(frames) != 2 || frames[0].InApp || !frames[1].InApp || !strings.HasSuffix(frames[1].Function, "handleFindUsers") {
+		t.Errorf("frames: got: %+v", frames)
+	}
+}
+
+func TestSentryReporter_Rejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	reporter, err := report.NewSentryReporter(strings.Replace(ts.URL, "http://", "http://public@", 1) + "/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reporter.Report(context.Background(), newReport()); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestNewSentryReporter_InvalidDSN(t *testing.T) {
+	for _, dsn := range []string{"", "https://sentry.io/1", "https://key@sentry.io/project", "://key@"} {
+		if _, err := report.NewSentryReporter(dsn); err == nil {
+			t.Errorf("%q: expected error", dsn)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package frs_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/TezzBhandari/frs"
+)
+
+func explode() {
+	panic("boom")
+}
+
+func recoverReport(ctx context.Context) (report *frs.Report) {
+	defer func() {
+		report = frs.NewPanicReport(ctx, recover())
+	}()
+	explode()
+	return nil
+}
+
+func TestNewPanicReport(t *testing.T) {
+	ctx := frs.NewContextWithRequestID(context.Background(), "req-1")
+	ctx = frs.NewContextWithUser(ctx, &frs.User{ID: 7})
+
+	report := recoverReport(ctx)
+	if report.Level != "fatal" || report.Type != "string" || report.Message != "boom" {
+		t.Errorf("got: %s %s %q", report.Level, report.Type, report.Message)
+	}
+	if report.RequestID != "req-1" || report.UserID != 7 {
+		t.Errorf("context: got: %q %d", report.RequestID, report.UserID)
+	}
+
+	// starts at the panic, not in the recovering function or the runtime
+	if len(report.Stack) < 2 {
+		t.Fatalf("stack too short: %+v", report.Stack)
+	}
+	if got := report.Stack[0].Function; !strings.HasSuffix(got, ".explode") {
+		t.Errorf("top frame: got: %s", got)
+	}
+	if got := report.Stack[1].Function; !strings.HasSuffix(got, ".recoverReport") {
+		t.Errorf("second frame: got: %s", got)
+	}
+}
+
+func TestNewErrorReport(t *testing.T) {
+	report := frs.NewErrorReport(context.Background(), errors.New("connection refused"))
+	if report.Level != "error" || report.Message != "connection refused" {
+		t.Errorf("got: %s %q", report.Level, report.Message)
+	}
+	if got := report.Stack[0].Function; !strings.HasSuffix(got, ".TestNewErrorReport") {
+		t.Errorf("top frame: got: %s", got)
+	}
+}
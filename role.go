@@ -0,0 +1,130 @@
+package frs
+
+import "context"
+
+// Role is the access level of a user.
+type Role string
+
+const (
+	RoleAdmin     Role = "admin"
+	RoleModerator Role = "moderator"
+	RoleOrganizer Role = "organizer"
+	RoleDonor     Role = "donor"
+)
+
+// Permission is a single action a role may be allowed to perform.
+type Permission string
+
+const (
+	// list every user and read their emails
+	PermReadUsers Permission = "users:read"
+	// update or delete any user account
+	PermWriteUsers Permission = "users:write"
+	// change the role of a user
+	PermAssignRoles Permission = "roles:assign"
+	// update or delete your own account
+	PermWriteProfile Permission = "profile:write"
+	// create fund raisers and edit the ones you organize
+	PermWriteFundRaisers Permission = "fundraisers:write"
+	// edit or delete any fund raiser
+	PermManageFundRaisers Permission = "fundraisers:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermReadUsers,
+		PermWriteUsers,
+		PermAssignRoles,
+		PermWriteProfile,
+		PermWriteFundRaisers,
+		PermManageFundRaisers,
+	},
+	RoleModerator: {
+		PermWriteProfile,
+		PermWriteFundRaisers,
+		PermManageFundRaisers,
+	},
+	RoleOrganizer: {
+		PermWriteProfile,
+		PermWriteFundRaisers,
+	},
+	RoleDonor: {
+		PermWriteProfile,
+	},
+}
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Valid reports whether p is a known permission.
+func (p Permission) Valid() bool {
+	return RoleAdmin.Can(p)
+}
+
+// Can reports whether the role grants the permission.
+func (r Role) Can(perm Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize returns EUNAUTHORIZED if there is no user attached to ctx and
+// EFORBIDDEN if the user's role does not grant perm. Requests made with an
+// api key are further limited to the key's scopes.
+func Authorize(ctx context.Context, perm Permission) error {
+	user := UserFromContext(ctx)
+	if user == nil {
+		return Errorf(EUNAUTHORIZED, "authentication required")
+	}
+
+	if !user.Role.Can(perm) {
+		return Errorf(EFORBIDDEN, "permission denied")
+	}
+
+	if key := APIKeyFromContext(ctx); key != nil && !key.HasScope(perm) {
+		return Errorf(EFORBIDDEN, "api key is missing scope %q", perm)
+	}
+
+	return nil
+}
+
+// AuthorizeOwner allows the owner of a resource holding perm, or anyone
+// holding anyPerm, to act on it.
+func AuthorizeOwner(ctx context.Context, ownerID int64, perm, anyPerm Permission) error {
+	if err := Authorize(ctx, anyPerm); err == nil {
+		return nil
+	}
+
+	if err := Authorize(ctx, perm); err != nil {
+		return err
+	}
+
+	if UserIDFromContext(ctx) != ownerID {
+		return Errorf(EFORBIDDEN, "permission denied")
+	}
+
+	return nil
+}
+
+// AuthorizeGrant returns EFORBIDDEN if a request made with an api key asks
+// to grant a scope the key does not hold, so a key can never mint a more
+// powerful one. Requests made with a session are only limited by the role.
+func AuthorizeGrant(ctx context.Context, scopes []Permission) error {
+	key := APIKeyFromContext(ctx)
+	if key == nil {
+		return nil
+	}
+
+	for _, scope := range scopes {
+		if !key.HasScope(scope) {
+			return Errorf(EFORBIDDEN, "api key cannot grant scope %q it does not hold", scope)
+		}
+	}
+	return nil
+}
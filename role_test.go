@@ -0,0 +1,71 @@
+package frs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TezzBhandari/frs"
+)
+
+func TestAuthorize(t *testing.T) {
+	ctx := context.Background()
+	if got := frs.ErrorCode(frs.Authorize(ctx, frs.PermReadUsers)); got != frs.EUNAUTHORIZED {
+		t.Errorf("anonymous: got: %q, want: %q", got, frs.EUNAUTHORIZED)
+	}
+
+	donor := frs.NewContextWithUser(ctx, &frs.User{ID: 1, Role: frs.RoleDonor})
+	if got := frs.ErrorCode(frs.Authorize(donor, frs.PermReadUsers)); got != frs.EFORBIDDEN {
+		t.Errorf("donor: got: %q, want: %q", got, frs.EFORBIDDEN)
+	}
+
+	admin := frs.NewContextWithUser(ctx, &frs.User{ID: 2, Role: frs.RoleAdmin})
+	if err := frs.Authorize(admin, frs.PermReadUsers); err != nil {
+		t.Errorf("admin: got: %v, want: nil", err)
+	}
+}
+
+func TestAuthorizeOwner(t *testing.T) {
+	organizer := frs.NewContextWithUser(context.Background(), &frs.User{ID: 1, Role: frs.RoleOrganizer})
+	if err := frs.AuthorizeOwner(organizer, 1, frs.PermWriteFundRaisers, frs.PermManageFundRaisers); err != nil {
+		t.Errorf("owner: got: %v, want: nil", err)
+	}
+
+	if got := frs.ErrorCode(frs.AuthorizeOwner(organizer, 2, frs.PermWriteFundRaisers, frs.PermManageFundRaisers)); got != frs.EFORBIDDEN {
+		t.Errorf("not owner: got: %q, want: %q", got, frs.EFORBIDDEN)
+	}
+
+	moderator := frs.NewContextWithUser(context.Background(), &frs.User{ID: 3, Role: frs.RoleModerator})
+	if err := frs.AuthorizeOwner(moderator, 1, frs.PermWriteFundRaisers, frs.PermManageFundRaisers); err != nil {
+		t.Errorf("moderator: got: %v, want: nil", err)
+	}
+}
+
+func TestAuthorizeAPIKeyScopes(t *testing.T) {
+	ctx := frs.NewContextWithUser(context.Background(), &frs.User{ID: 1, Role: frs.RoleAdmin})
+	ctx = frs.NewContextWithAPIKey(ctx, &frs.APIKey{Scopes: []frs.Permission{frs.PermReadUsers}})
+
+	if err := frs.Authorize(ctx, frs.PermReadUsers); err != nil {
+		t.Errorf("in scope: got: %v, want: nil", err)
+	}
+
+	if got := frs.ErrorCode(frs.Authorize(ctx, frs.PermWriteUsers)); got != frs.EFORBIDDEN {
+		t.Errorf("out of scope: got: %q, want: %q", got, frs.EFORBIDDEN)
+	}
+}
+
+func TestAuthorizeGrant(t *testing.T) {
+	ctx := frs.NewContextWithUser(context.Background(), &frs.User{ID: 1, Role: frs.RoleAdmin})
+	if err := frs.AuthorizeGrant(ctx, []frs.Permission{frs.PermWriteUsers}); err != nil {
+		t.Errorf("session: got: %v, want: nil", err)
+	}
+
+	ctx = frs.NewContextWithAPIKey(ctx, &frs.APIKey{Scopes: []frs.Permission{frs.PermWriteProfile}})
+	if err := frs.AuthorizeGrant(ctx, []frs.Permission{frs.PermWriteProfile}); err != nil {
+		t.Errorf("held scope: got: %v, want: nil", err)
+	}
+
+	// the owner is an admin, the key still can't hand out more than it has
+	if got := frs.ErrorCode(frs.AuthorizeGrant(ctx, []frs.Permission{frs.PermWriteProfile, frs.PermWriteUsers})); got != frs.EFORBIDDEN {
+		t.Errorf("escalation: got: %q, want: %q", got, frs.EFORBIDDEN)
+	}
+}
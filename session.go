@@ -0,0 +1,33 @@
+package frs
+
+import (
+	"context"
+	"time"
+)
+
+// Session is one login of a user, every access token belongs to a session.
+type Session struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// set when the session is the one making the request
+	Current bool `json:"current"`
+}
+
+// Client describes where a request comes from.
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+type SessionService interface {
+	// lists the sessions that are neither revoked nor expired
+	FindSessions(ctx context.Context, userID int64) ([]*Session, error)
+	// return NOTFOUND Error
+	RevokeSession(ctx context.Context, userID, id int64) error
+	RevokeSessions(ctx context.Context, userID int64) error
+}
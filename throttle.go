@@ -0,0 +1,157 @@
+package frs
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// LoginAttempts is the failure record kept for one account or ip address.
+type LoginAttempts struct {
+	Failures     int
+	LastFailure  time.Time
+	BlockedUntil time.Time
+}
+
+// LoginAttemptStore persists failure records. Implementations must apply
+// UpdateLoginAttempts atomically so concurrent logins can't lose a failure.
+type LoginAttemptStore interface {
+	// returns a zero record when key has no failures
+	FindLoginAttempts(ctx context.Context, key string) (*LoginAttempts, error)
+	UpdateLoginAttempts(ctx context.Context, key string, fn func(*LoginAttempts)) (*LoginAttempts, error)
+	DeleteLoginAttempts(ctx context.Context, key string) error
+}
+
+// ThrottlePolicy decides how long a key is blocked after repeated failures.
+type ThrottlePolicy struct {
+	// failures allowed before any delay kicks in
+	FreeAttempts int
+	// delay after the first throttled failure, doubled on every further one
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// failures after which the key is locked out for LockoutDuration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	// failures older than this are forgotten
+	ResetAfter time.Duration
+}
+
+var (
+	DefaultAccountThrottlePolicy = ThrottlePolicy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Minute,
+		LockoutAfter:    10,
+		LockoutDuration: 15 * time.Minute,
+		ResetAfter:      time.Hour,
+	}
+
+	// many users can share one address so ips get more room
+	DefaultIPThrottlePolicy = ThrottlePolicy{
+		FreeAttempts:    20,
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Minute,
+		LockoutAfter:    100,
+		LockoutDuration: time.Hour,
+		ResetAfter:      time.Hour,
+	}
+)
+
+func (p ThrottlePolicy) blockedUntil(failures int, now time.Time) time.Time {
+	if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+		return now.Add(p.LockoutDuration)
+	}
+
+	if failures <= p.FreeAttempts {
+		return time.Time{}
+	}
+
+	// cap the exponent so the shift can't overflow
+	exp := min(failures-p.FreeAttempts-1, 30)
+	delay := p.BaseDelay << exp
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return now.Add(delay)
+}
+
+// LoginThrottle slows down credential stuffing by tracking failed logins per
+// account and per ip address.
+type LoginThrottle struct {
+	Store   LoginAttemptStore
+	Account ThrottlePolicy
+	IP      ThrottlePolicy
+	Now     func() time.Time
+}
+
+func NewLoginThrottle(store LoginAttemptStore) *LoginThrottle {
+	return &LoginThrottle{
+		Store:   store,
+		Account: DefaultAccountThrottlePolicy,
+		IP:      DefaultIPThrottlePolicy,
+		Now:     time.Now,
+	}
+}
+
+// Allow returns ERATELIMIT Error and how long to wait if either the account
+// or the ip address is currently blocked.
+func (t *LoginThrottle) Allow(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := t.Now()
+
+	var wait time.Duration
+	for key := range t.policies(email, ip) {
+		attempts, err := t.Store.FindLoginAttempts(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+
+		if d := attempts.BlockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+
+	if wait > 0 {
+		return wait, Errorf(ERATELIMIT, "too many failed login attempts, retry in %s", wait.Round(time.Second))
+	}
+	return 0, nil
+}
+
+// Fail records a failed login for the account and the ip address.
+func (t *LoginThrottle) Fail(ctx context.Context, email, ip string) error {
+	now := t.Now()
+
+	for key, policy := range t.policies(email, ip) {
+		_, err := t.Store.UpdateLoginAttempts(ctx, key, func(attempts *LoginAttempts) {
+			if now.Sub(attempts.LastFailure) > policy.ResetAfter {
+				attempts.Failures = 0
+			}
+			attempts.Failures++
+			attempts.LastFailure = now
+			attempts.BlockedUntil = policy.blockedUntil(attempts.Failures, now)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeed clears the account's failures. The ip record is kept so an attacker
+// can't reset it by logging into an account of their own.
+func (t *LoginThrottle) Succeed(ctx context.Context, email string) error {
+	return t.Store.DeleteLoginAttempts(ctx, accountThrottleKey(email))
+}
+
+func (t *LoginThrottle) policies(email, ip string) map[string]ThrottlePolicy {
+	policies := map[string]ThrottlePolicy{
+		accountThrottleKey(email): t.Account,
+	}
+	if ip != "" {
+		policies["ip:"+ip] = t.IP
+	}
+	return policies
+}
+
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
@@ -0,0 +1,57 @@
+package frs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TezzBhandari/frs"
+	"github.com/TezzBhandari/frs/inmem"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	throttle := frs.NewLoginThrottle(inmem.NewLoginAttemptStore())
+	throttle.Now = func() time.Time { return now }
+
+	for i := 0; i < throttle.Account.FreeAttempts; i++ {
+		if err := throttle.Fail(ctx, "jane@example.com", "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := throttle.Allow(ctx, "jane@example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("free attempts: got: %v, want: nil", err)
+	}
+
+	// first throttled failure waits BaseDelay, the next one twice as long
+	throttle.Fail(ctx, "jane@example.com", "10.0.0.1")
+	throttle.Fail(ctx, "JANE@example.com", "10.0.0.2")
+	wait, err := throttle.Allow(ctx, "jane@example.com", "10.0.0.3")
+	if got := frs.ErrorCode(err); got != frs.ERATELIMIT {
+		t.Fatalf("got: %q, want: %q", got, frs.ERATELIMIT)
+	}
+	if want := 2 * throttle.Account.BaseDelay; wait != want {
+		t.Errorf("wait: got: %s, want: %s", wait, want)
+	}
+
+	now = now.Add(wait)
+	if _, err := throttle.Allow(ctx, "jane@example.com", "10.0.0.3"); err != nil {
+		t.Errorf("after backoff: got: %v, want: nil", err)
+	}
+
+	for i := 0; i < throttle.Account.LockoutAfter; i++ {
+		throttle.Fail(ctx, "jane@example.com", "10.0.0.1")
+	}
+	if wait, _ := throttle.Allow(ctx, "jane@example.com", ""); wait != throttle.Account.LockoutDuration {
+		t.Errorf("lockout: got: %s, want: %s", wait, throttle.Account.LockoutDuration)
+	}
+
+	if err := throttle.Succeed(ctx, "jane@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := throttle.Allow(ctx, "jane@example.com", ""); err != nil {
+		t.Errorf("after success: got: %v, want: nil", err)
+	}
+}
@@ -13,8 +13,12 @@ type User struct {
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
 	Password  string    `json:"password,omitempty"`
+	Role      Role      `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// incremented on every profile or role change, used for optimistic
+	// concurrency control
+	Version int `json:"version"`
 }
 
 func (u *User) FromJson(v io.ReadCloser) error {
@@ -22,33 +26,50 @@ func (u *User) FromJson(v io.ReadCloser) error {
 }
 
 func (u *User) Validate() error {
+	v := &validation{}
+	u.validateProfile(v)
+	validatePassword(v, u.Password)
+	return v.err()
+}
 
-	if u.Username == "" {
-		return Errorf(EBADREQUEST, "username required")
-	}
+func ValidatePassword(password string) error {
+	v := &validation{}
+	validatePassword(v, password)
+	return v.err()
+}
 
-	if u.Email == "" {
-		return Errorf(EBADREQUEST, "email required")
+func validatePassword(v *validation, password string) {
+	if password == "" {
+		v.add("password", FieldRequired, "password required")
+	} else if len(password) < 8 {
+		v.add("password", FieldTooShort, "password should be at least 8 character long")
 	}
+}
 
-	if !validEmail(u.Email) {
-		return Errorf(EBADREQUEST, "invalid email")
-	}
+// ValidateProfile validates the fields a user can change after signing up.
+func (u *User) ValidateProfile() error {
+	v := &validation{}
+	u.validateProfile(v)
+	return v.err()
+}
 
-	if u.Password == "" {
-		return Errorf(EBADREQUEST, "password required")
+func (u *User) validateProfile(v *validation) {
+	if u.Username == "" {
+		v.add("username", FieldRequired, "username required")
 	}
 
-	if len(u.Password) < 8 {
-		return Errorf(EBADREQUEST, "password should be at least 8 character long")
+	if u.Email == "" {
+		v.add("email", FieldRequired, "email required")
+	} else if !validEmail(u.Email) {
+		v.add("email", FieldInvalid, "invalid email")
 	}
-
-	return nil
 }
 
 type UpdateUser struct {
 	Username *string `json:"username"`
 	Email    *string `json:"email"`
+	// version the update is based on, nil skips the check
+	Version *int `json:"version"`
 }
 
 // func (upd *UpdateUser) validate() error {
@@ -64,6 +85,17 @@ type FilterUser struct {
 
 	Limit  int `json:"limit"`
 	Offset int `json:"offset"`
+	// continue after the row the cursor was issued for, can't be combined
+	// with Offset
+	Cursor string `json:"cursor"`
+}
+
+func (f *FilterUser) Validate() error {
+	if f.Limit < 0 || f.Offset < 0 {
+		return Errorf(EINVALID, "limit and offset must not be negative")
+	}
+
+	return nil
 }
 
 type UserService interface {
@@ -71,9 +103,18 @@ type UserService interface {
 	// return NOTFOUND Error | UNAUTHORIZED Error
 	FindUserById(ctx context.Context, id int64) (*User, error)
 	UpdateUser(ctx context.Context, id int64, upd UpdateUser) (*User, error)
+	// ReplaceUser overwrites the profile fields with those of user.
+	// return NOTFOUND | INVALID | CONFLICT Error
+	ReplaceUser(ctx context.Context, id int64, user *User) (*User, error)
+	// returns one page of matches and the total number of matches
 	// return NOTFOUND | UNAUTHORIZED Error
 	FindUsers(ctx context.Context, filter *FilterUser) ([]*User, int, error)
 	DeleteUser(ctx context.Context, id int64) error
+	// return NOTFOUND | UNAUTHORIZED | FORBIDDEN Error
+	AssignRole(ctx context.Context, id int64, role Role) (*User, error)
+	// SetPassword replaces the password and revokes every session of the user.
+	// return NOTFOUND | INVALID | UNAUTHORIZED | FORBIDDEN Error
+	SetPassword(ctx context.Context, id int64, password string) error
 }
 
 func validEmail(email string) bool {
@@ -21,3 +21,15 @@ func InvalidFundRaiserIdMsg() string {
 func DoesNotExistMsg(v string) string {
 	return fmt.Sprintf("%s does not exist", v)
 }
+
+func InvalidAPIKeyIdMsg() string {
+	return "invalid api key id"
+}
+
+func InvalidSessionIdMsg() string {
+	return "invalid session id"
+}
+
+func StaleVersionMsg(v string) string {
+	return fmt.Sprintf("%s was modified, fetch the latest version and retry", v)
+}